@@ -2,7 +2,12 @@ module netscan
 
 go 1.25.2
 
-require github.com/jessevdk/go-flags v1.6.1
+require (
+	github.com/jessevdk/go-flags v1.6.1
+	github.com/pterm/pterm v0.12.82
+	github.com/stretchr/testify v1.11.1
+	golang.org/x/sys v0.33.0
+)
 
 require (
 	atomicgo.dev/cursor v0.2.0 // indirect
@@ -14,11 +19,8 @@ require (
 	github.com/lithammer/fuzzysearch v1.1.8 // indirect
 	github.com/mattn/go-runewidth v0.0.16 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/pterm/pterm v0.12.82 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	github.com/stretchr/testify v1.11.1 // indirect
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
-	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/term v0.32.0 // indirect
 	golang.org/x/text v0.26.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -1,29 +1,93 @@
 package network
 
 import (
+	"context"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"iter"
+	"math"
+	"math/bits"
 	"net"
 	"net/netip"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Default maximum number of addresses per single scan.
+const DefaultMaxHosts = 65536
+
+// The scan range exceeds the maximum number of addresses.
+var ErrTooManyHosts = errors.New("address range exceeds the hosts limit")
+
+// Maximum number of the ranges of an IPv4 octet ranges expression.
+const maxOctetRanges = 1 << 16
+
+// Timeout of the host name targets resolution.
+const resolveTimeout = 5 * time.Second
+
+// Inclusive range of addresses of the same family.
+type addrRange struct {
+	first netip.Addr
+	last  netip.Addr
+}
+
 type AddrParser struct {
-	hostsFirst netip.Addr
-	hostsLast  netip.Addr
-	cidr       netip.Prefix
-	length     int
-	isVerbose  bool // TODO verbosity is not implemented yet
+	hostsFirst  netip.Addr
+	hostsLast   netip.Addr
+	cidr        netip.Prefix
+	length      uint64
+	maxHosts    uint64
+	targets     []addrRange // as added, may overlap
+	excluded    []addrRange
+	ranges      []addrRange // sorted and merged targets with exclusions cut out
+	offsets     []uint64    // number of addresses preceding each of the ranges
+	allowPublic bool
+	ipv6Targets *IPv6TargetsGenerator
+	names       map[netip.Addr]string // host names given as targets
+	isVerbose   bool                  // TODO verbosity is not implemented yet
 }
 
 // AddrParser performs parsing of CIDR notation or single IP address string.
 func NewAddrParser() *AddrParser {
-	return &AddrParser{}
+	return &AddrParser{maxHosts: DefaultMaxHosts}
 }
 
 func (p *AddrParser) SetVerbosity(on bool) {
 	p.isVerbose = on
 }
 
+// Sets the maximum number of addresses to scan, DefaultMaxHosts by default.
+// The targets exceeding it are rejected with ErrTooManyHosts.
+func (p *AddrParser) SetMaxHosts(n uint64) {
+	p.maxHosts = n
+}
+
+// Allows targets outside of the private and special-purpose networks.
+func (p *AddrParser) SetAllowPublic(on bool) {
+	p.allowPublic = on
+}
+
+// Enables picking the probable hosts in the IPv6 prefixes
+// which are too large to scan every address, nil disables.
+func (p *AddrParser) SetIPv6Generator(g *IPv6TargetsGenerator) {
+	p.ipv6Targets = g
+}
+
+// Returns true if any of the hosts to scan is outside of the private
+// and special-purpose networks.
+func (p *AddrParser) HasPublicHosts() bool {
+	for _, r := range p.ranges {
+		if !isPrivateRange(r) {
+			return true
+		}
+	}
+	return false
+}
+
 // Get first host address in the parsed range.
 func (p *AddrParser) GetHostsFirst() netip.Addr {
 	return p.hostsFirst
@@ -35,65 +99,490 @@ func (p *AddrParser) GetHostsLast() netip.Addr {
 }
 
 // Get CIDR prefix of the parsed range.
+// For multiple targets, it's the smallest prefix covering all of them,
+// or an invalid prefix if both IPv4 and IPv6 addresses are targeted.
 func (p *AddrParser) GetCIDR() netip.Prefix {
 	return p.cidr
 }
 
 // Get number of hosts in the parsed range
-func (p *AddrParser) GetHostsLength() int {
+func (p *AddrParser) GetHostsLength() uint64 {
 	return p.length
 }
 
+// Returns true if the address is among the hosts to scan.
+func (p *AddrParser) Contains(addr netip.Addr) bool {
+	i := sort.Search(len(p.ranges), func(i int) bool {
+		return p.ranges[i].last.Compare(addr) >= 0
+	})
+	return i < len(p.ranges) && p.ranges[i].first.Compare(addr) <= 0
+}
+
+// Returns the host name given as the target resolved to the address,
+// or an empty string if the address wasn't resolved from a name.
+func (p *AddrParser) GetTargetName(addr netip.Addr) string {
+	return p.names[addr]
+}
+
 // Iterates over all host addresses in the parsed range,
 // excluding network and broadcast addresses for IPv4.
 func (p *AddrParser) Hosts() iter.Seq[netip.Addr] {
 	return func(yield func(netip.Addr) bool) {
-		for addr := p.hostsFirst; addr.IsValid(); addr = addr.Next() {
-			if !yield(addr) {
-				return
+		for _, r := range p.ranges {
+			for addr := r.first; addr.IsValid(); addr = addr.Next() {
+				if !yield(addr) {
+					return
+				}
+				if addr == r.last {
+					break
+				}
 			}
-			if addr == p.hostsLast {
+		}
+	}
+}
+
+// Iterates over the same host addresses as Hosts,
+// but in a pseudo-random order determined by the seed.
+func (p *AddrParser) HostsShuffled(seed uint64) iter.Seq[netip.Addr] {
+	return func(yield func(netip.Addr) bool) {
+		if p.length == 0 {
+			return
+		}
+		perm := newPermutation(p.length, seed)
+		for i := range p.length {
+			if !yield(p.hostAt(perm.At(i))) {
 				return
 			}
 		}
 	}
 }
 
+// Returns the i-th host address in the ascending order.
+func (p *AddrParser) hostAt(i uint64) netip.Addr {
+	n := sort.Search(len(p.offsets), func(n int) bool {
+		return p.offsets[n] > i
+	}) - 1
+	return addOffset(p.ranges[n].first, i-p.offsets[n])
+}
+
 // Parses CIDR notation or single IP address string.
-// Populates hostsFirst and hostsLast fields.
+// Replaces all the previously added targets and exclusions.
 //
 // Returns:
 //   - error value or nil on success.
 func (p *AddrParser) ParseCidrOrAddr(s string) error {
-	prefix, err := netip.ParsePrefix(s)
+	*p = AddrParser{
+		maxHosts:    p.maxHosts,
+		allowPublic: p.allowPublic,
+		ipv6Targets: p.ipv6Targets,
+		isVerbose:   p.isVerbose,
+	}
+	return p.AddTarget(s)
+}
+
+// Adds a target expression to the scan range. Accepted forms are:
+//   - single IP address: 192.168.1.5, fd00::1;
+//   - CIDR notation: 192.168.1.0/24, network and broadcast IPv4 addresses are skipped;
+//   - dash range: 10.0.0.5-10.0.0.50, fd00::1-fd00::ff;
+//   - IPv4 octet ranges and wildcards: 10.0.0.5-50, 192.168.1-3.*
+//     (the * wildcard in the last octet skips .0 and .255 addresses);
+//   - host name, resolved to A and AAAA addresses: nas01.lab;
+//   - host name with the prefix length, the subnet around its IPv4
+//     (length up to 32) or IPv6 addresses: nas01.lab/24.
+//
+// For IPv6 prefixes exceeding the hosts limit, only the addresses picked
+// by the IPv6 generator are added, if it's set with SetIPv6Generator.
+//
+// Only private and special-purpose (CGNAT, link-local) addresses
+// are allowed, unless SetAllowPublic is on. Overlapping targets
+// are merged, so each address is scanned once.
+//
+// Returns:
+//   - error value or nil on success.
+func (p *AddrParser) AddTarget(s string) error {
+	ranges, err := p.generateIPv6Ranges(s)
+	var resolved []netip.Addr
+	if ranges == nil && err == nil {
+		ranges, resolved, err = parseRanges(s, true)
+	}
 	if err != nil {
-		// maybe it's a single IP address without a mask
-		ip, err2 := netip.ParseAddr(s)
-		if err2 != nil {
-			return err
-		}
-		if !ip.IsPrivate() {
+		return err
+	}
+	for _, r := range ranges {
+		if !p.allowPublic && !isPrivateRange(r) {
 			return errors.New("not a private network")
 		}
-		p.hostsFirst = ip
-		p.hostsLast = ip
-		p.cidr = netip.PrefixFrom(ip, ip.BitLen())
-		p.length = 1
-		return nil
 	}
-	// it's a CIDR range
-	prefix = prefix.Masked()
-	p.cidr = prefix
-	err = p.populateHosts()
+	targets := p.targets
+	p.targets = append(p.targets, ranges...)
+	err = p.update()
+	if err != nil {
+		// keep the valid state
+		p.targets = targets
+		p.update()
+		return err
+	}
+	if len(resolved) > 0 {
+		if p.names == nil {
+			p.names = make(map[netip.Addr]string)
+		}
+		name, _, _ := strings.Cut(strings.TrimSpace(s), "/")
+		for _, addr := range resolved {
+			p.names[addr] = name
+		}
+	}
+	return nil
+}
+
+// Returns the ranges of the hosts picked by the IPv6 generator
+// if s is an IPv6 prefix too large to scan, or nil otherwise.
+func (p *AddrParser) generateIPv6Ranges(s string) ([]addrRange, error) {
+	if p.ipv6Targets == nil {
+		return nil, nil
+	}
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(s))
+	if err != nil || !prefix.Addr().Is6() || prefix.Addr().Is4In6() {
+		return nil, nil
+	}
+	if hostBits := 128 - prefix.Bits(); hostBits < 64 && uint64(1)<<hostBits <= p.maxHosts {
+		return nil, nil
+	}
+	ranges := []addrRange{}
+	for _, addr := range p.ipv6Targets.Generate(prefix) {
+		ranges = append(ranges, addrRange{addr, addr})
+	}
+	return ranges, nil
+}
+
+// Excludes the addresses of the target expression from the scan range,
+// accepting the same forms as AddTarget. For CIDR notation,
+// all the addresses of the prefix are excluded.
+//
+// Returns:
+//   - error value or nil on success.
+func (p *AddrParser) Exclude(s string) error {
+	ranges, _, err := parseRanges(s, false)
 	if err != nil {
 		return err
 	}
-	if !p.hostsFirst.IsPrivate() || !p.hostsLast.IsPrivate() {
-		return errors.New("not a private network")
+	p.excluded = append(p.excluded, ranges...)
+	return p.update()
+}
+
+// Rebuilds the sorted list of ranges to scan.
+func (p *AddrParser) update() error {
+	ranges := subtractRanges(mergeRanges(p.targets), mergeRanges(p.excluded))
+	offsets := make([]uint64, len(ranges))
+	var length uint64
+	for i, r := range ranges {
+		offsets[i] = length
+		// the size is saturated, so it's never counted up to the limit
+		size := rangeSize(r)
+		if size == math.MaxUint64 || size > p.maxHosts-length {
+			return fmt.Errorf("%w of %d", ErrTooManyHosts, p.maxHosts)
+		}
+		length += size
+	}
+	p.ranges = ranges
+	p.offsets = offsets
+	p.length = length
+	if len(ranges) == 0 {
+		p.hostsFirst = netip.Addr{}
+		p.hostsLast = netip.Addr{}
+		p.cidr = netip.Prefix{}
+		return nil
 	}
+	p.hostsFirst = ranges[0].first
+	p.hostsLast = ranges[len(ranges)-1].last
+	p.cidr = coveringPrefix(p.hostsFirst, p.hostsLast)
 	return nil
 }
 
+// Parses the target expression into address ranges.
+// If hostsOnly is set, network and broadcast IPv4 addresses
+// of CIDR ranges are skipped.
+// For host names, the resolved addresses are returned as well.
+func parseRanges(s string, hostsOnly bool) ([]addrRange, []netip.Addr, error) {
+	s = strings.TrimSpace(s)
+	if host, bits, ok := strings.Cut(s, "/"); ok {
+		prefix, err := netip.ParsePrefix(s)
+		if err == nil {
+			r, err := prefixRange(prefix, hostsOnly)
+			return r, nil, err
+		}
+		if !isHostName(host) {
+			return nil, nil, err
+		}
+		return resolveRanges(host, bits, hostsOnly)
+	}
+	ip, err := netip.ParseAddr(s)
+	if err == nil {
+		return []addrRange{{ip, ip}}, nil, nil
+	}
+	if first, last, ok := strings.Cut(s, "-"); ok {
+		firstIP, err1 := netip.ParseAddr(first)
+		lastIP, err2 := netip.ParseAddr(last)
+		if err1 == nil && err2 == nil {
+			if firstIP.Is4() != lastIP.Is4() || lastIP.Less(firstIP) {
+				return nil, nil, errors.New("invalid address range")
+			}
+			return []addrRange{{firstIP, lastIP}}, nil, nil
+		}
+	}
+	if strings.Count(s, ".") == 3 && strings.Trim(s, "0123456789.-*") == "" {
+		r, err := parseOctetRanges(s)
+		return r, nil, err
+	}
+	if isHostName(s) {
+		return resolveRanges(s, "", hostsOnly)
+	}
+	return nil, nil, err
+}
+
+// Returns the range of the prefix addresses.
+func prefixRange(prefix netip.Prefix, hostsOnly bool) ([]addrRange, error) {
+	p := &AddrParser{cidr: prefix.Masked()}
+	var err error
+	if hostsOnly {
+		err = p.populateHosts()
+	} else {
+		p.hostsFirst = p.cidr.Addr()
+		p.hostsLast, err = calculateLastHostInRange(p.cidr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []addrRange{{p.hostsFirst, p.hostsLast}}, nil
+}
+
+// Resolves the host name with the system resolver and returns
+// the ranges of its addresses along with the addresses themselves.
+// If the prefix length is given, the ranges are the subnets around
+// the addresses: IPv4 ones for the lengths up to 32, IPv6 otherwise.
+func resolveRanges(host string, bits string, hostsOnly bool) ([]addrRange, []netip.Addr, error) {
+	family := "ip"
+	prefixLen := -1
+	if len(bits) > 0 {
+		var err error
+		prefixLen, err = strconv.Atoi(bits)
+		if err != nil || prefixLen < 0 || prefixLen > 128 {
+			return nil, nil, errors.New("invalid prefix length: " + bits)
+		}
+		family = "ip4"
+		if prefixLen > 32 {
+			family = "ip6"
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, family, host)
+	if err != nil {
+		return nil, nil, err
+	}
+	ranges := []addrRange{}
+	for i, addr := range addrs {
+		addr = addr.Unmap()
+		addrs[i] = addr
+		if prefixLen < 0 {
+			ranges = append(ranges, addrRange{addr, addr})
+			continue
+		}
+		r, err := prefixRange(netip.PrefixFrom(addr, prefixLen), hostsOnly)
+		if err != nil {
+			return nil, nil, err
+		}
+		ranges = append(ranges, r...)
+	}
+	return ranges, addrs, nil
+}
+
+// Returns true if s looks like a DNS host name.
+func isHostName(s string) bool {
+	if len(s) == 0 || len(s) > 253 {
+		return false
+	}
+	hasLetter := false
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			hasLetter = true
+		case c >= '0' && c <= '9', c == '-', c == '.', c == '_':
+		default:
+			return false
+		}
+	}
+	return hasLetter
+}
+
+// Parses IPv4 address with octet ranges and wildcards, like 192.168.1-3.*
+func parseOctetRanges(s string) ([]addrRange, error) {
+	var lo, hi [4]int
+	// number of the ranges of the last octet
+	count := 1
+	for i, octet := range strings.Split(s, ".") {
+		switch {
+		case octet == "*" && i == 3:
+			lo[i], hi[i] = 1, 254
+		case octet == "*":
+			lo[i], hi[i] = 0, 255
+		default:
+			first, last, isRange := strings.Cut(octet, "-")
+			var err error
+			lo[i], err = parseOctet(first)
+			if err != nil {
+				return nil, err
+			}
+			hi[i] = lo[i]
+			if isRange {
+				hi[i], err = parseOctet(last)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if hi[i] < lo[i] {
+				return nil, errors.New("invalid octet range")
+			}
+		}
+		if i < 3 {
+			count *= hi[i] - lo[i] + 1
+		}
+	}
+	// the total number of addresses is limited by the caller,
+	// this keeps the list of the ranges reasonable: up to 10.*.*.*
+	if count > maxOctetRanges {
+		return nil, errors.New("too many octet ranges")
+	}
+	ranges := []addrRange{}
+	for a := lo[0]; a <= hi[0]; a++ {
+		for b := lo[1]; b <= hi[1]; b++ {
+			for c := lo[2]; c <= hi[2]; c++ {
+				ranges = append(ranges, addrRange{
+					netip.AddrFrom4([4]byte{byte(a), byte(b), byte(c), byte(lo[3])}),
+					netip.AddrFrom4([4]byte{byte(a), byte(b), byte(c), byte(hi[3])}),
+				})
+			}
+		}
+	}
+	return ranges, nil
+}
+
+func parseOctet(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > 255 || len(s) > 3 {
+		return 0, errors.New("invalid octet: " + s)
+	}
+	return n, nil
+}
+
+// Networks allowed to scan by default: private address spaces,
+// as reported by netip.Addr.IsPrivate, and special-purpose ones
+// which aren't routed over the Internet.
+var privateNetworks = []netip.Prefix{
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT, RFC 6598
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("fe80::/10"),
+}
+
+// Returns true if the whole range belongs to a single allowed network.
+func isPrivateRange(r addrRange) bool {
+	first := r.first.WithZone("")
+	last := r.last.WithZone("")
+	for _, n := range privateNetworks {
+		if n.Contains(first) && n.Contains(last) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the smallest prefix containing both addresses,
+// or an invalid prefix for different address families.
+func coveringPrefix(first, last netip.Addr) netip.Prefix {
+	if first.Is4() != last.Is4() {
+		return netip.Prefix{}
+	}
+	a := first.WithZone("").AsSlice()
+	b := last.WithZone("").AsSlice()
+	common := 0
+	for i := range a {
+		common += bits.LeadingZeros8(a[i] ^ b[i])
+		if a[i] != b[i] {
+			break
+		}
+	}
+	prefix, _ := first.WithZone("").Prefix(common)
+	return prefix
+}
+
+// Returns the ranges sorted, with overlapping and adjacent ones joined.
+func mergeRanges(ranges []addrRange) []addrRange {
+	sorted := slices.Clone(ranges)
+	slices.SortFunc(sorted, func(a, b addrRange) int {
+		return a.first.Compare(b.first)
+	})
+	merged := []addrRange{}
+	for _, r := range sorted {
+		if n := len(merged); n > 0 {
+			prev := &merged[n-1]
+			next := prev.last.Next()
+			if prev.last.Compare(r.first) >= 0 || (next.IsValid() && next == r.first) {
+				if prev.last.Less(r.last) {
+					prev.last = r.last
+				}
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// Cuts the excluded addresses out of the ranges, both lists must be merged.
+func subtractRanges(ranges, excluded []addrRange) []addrRange {
+	result := []addrRange{}
+	for _, r := range ranges {
+		for _, e := range excluded {
+			if e.last.Less(r.first) || r.last.Less(e.first) {
+				continue
+			}
+			if r.first.Less(e.first) {
+				result = append(result, addrRange{r.first, e.first.Prev()})
+			}
+			if !e.last.Less(r.last) {
+				r.first = netip.Addr{}
+				break
+			}
+			r.first = e.last.Next()
+		}
+		if r.first.IsValid() {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// Returns the number of addresses in the range,
+// saturated at math.MaxUint64 for large IPv6 ranges.
+func rangeSize(r addrRange) uint64 {
+	a := r.first.As16()
+	b := r.last.As16()
+	hi := binary.BigEndian.Uint64(b[:8]) - binary.BigEndian.Uint64(a[:8])
+	loA := binary.BigEndian.Uint64(a[8:])
+	loB := binary.BigEndian.Uint64(b[8:])
+	lo := loB - loA
+	if loB < loA {
+		hi--
+	}
+	if hi != 0 || lo == math.MaxUint64 {
+		return math.MaxUint64
+	}
+	return lo + 1
+}
+
 func (p *AddrParser) populateHosts() error {
 	network := p.cidr.Addr()
 	if !network.IsValid() {
@@ -105,7 +594,6 @@ func (p *AddrParser) populateHosts() error {
 		// single address
 		p.hostsFirst = network
 		p.hostsLast = network
-		p.length = 1
 		return nil
 	}
 	next := network.Next()
@@ -116,7 +604,6 @@ func (p *AddrParser) populateHosts() error {
 		// two addresses only; no broadcast for IPv4
 		p.hostsFirst = network
 		p.hostsLast = next
-		p.length = 2
 		return nil
 	}
 	// larger networks
@@ -124,21 +611,14 @@ func (p *AddrParser) populateHosts() error {
 	if err != nil {
 		return err
 	}
-	length, err := limitRangeLength(p.cidr)
-	if err != nil {
-		return err
-	}
 	if is4 {
 		// for IPv4 networks skip network and broadcast addresses
 		p.hostsFirst = next
 		p.hostsLast = last.Prev()
-		p.length = length - 2
-
 	} else {
 		// for IPv6, use all addresses
 		p.hostsFirst = network
 		p.hostsLast = last
-		p.length = length
 	}
 	if !p.hostsLast.IsValid() {
 		return errors.New("failed to calculate last address")
@@ -172,25 +652,22 @@ func calculateLastHostInRange(prefix netip.Prefix) (netip.Addr, error) {
 	return last, nil
 }
 
-func limitRangeLength(prefix netip.Prefix) (int, error) {
-	totalBits := 128
-	if prefix.Addr().Is4() {
-		totalBits = 32
+// Returns the address following addr by offset positions.
+func addOffset(addr netip.Addr, offset uint64) netip.Addr {
+	if addr.Is4() {
+		b := addr.As4()
+		v := uint64(binary.BigEndian.Uint32(b[:])) + offset
+		binary.BigEndian.PutUint32(b[:], uint32(v))
+		return netip.AddrFrom4(b)
 	}
-
-	hostBits := totalBits - prefix.Bits()
-	if hostBits < 0 {
-		return 0, errors.New("invalid CIDR prefix length")
+	b := addr.As16()
+	lo := binary.BigEndian.Uint64(b[8:])
+	hi := binary.BigEndian.Uint64(b[:8])
+	sum := lo + offset
+	if sum < lo {
+		hi++
 	}
-
-	if hostBits > 16 {
-		return 0, errors.New("address range exceeds 65536")
-	}
-
-	count := uint64(1) << hostBits
-	if count > 65536 {
-		return 0, errors.New("address range exceeds 65536")
-	}
-
-	return int(count), nil
+	binary.BigEndian.PutUint64(b[8:], sum)
+	binary.BigEndian.PutUint64(b[:8], hi)
+	return netip.AddrFrom16(b).WithZone(addr.Zone())
 }
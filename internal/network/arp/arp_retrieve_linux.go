@@ -5,6 +5,7 @@ import (
 	"net"
 	"net/netip"
 	"os"
+	"os/exec"
 	"strings"
 )
 
@@ -50,3 +51,55 @@ func RetrieveArpTable() ([]ArpInfo, error) {
 	}
 	return table, nil
 }
+
+/*
+Example "ip -6 neigh show" output:
+
+fe80::1 dev enp3s0 lladdr 1a:90:05:00:01:02 router REACHABLE
+fd00::15 dev enp3s0 lladdr c6:c4:d3:00:01:02 STALE
+fd00::20 dev enp3s0 FAILED
+*/
+
+// Parses the "ip -6 neigh show" output (IPv6 neighbor cache)
+// and returns a slice of IP - MAC pairs
+// or (nil, error) in case of an error.
+// Link-local addresses are zoned with the interface name.
+func RetrieveNeighborTable() ([]ArpInfo, error) {
+	data, err := exec.Command("ip", "-6", "neigh", "show").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	table := make([]ArpInfo, 0)
+	for l := range strings.Lines(string(data)) {
+		tokens := strings.Fields(l)
+		if len(tokens) < 5 {
+			continue
+		}
+		ip, err := netip.ParseAddr(tokens[0])
+		if err != nil {
+			continue
+		}
+		var dev, lladdr string
+		for i := 1; i+1 < len(tokens); i++ {
+			switch tokens[i] {
+			case "dev":
+				dev = tokens[i+1]
+			case "lladdr":
+				lladdr = tokens[i+1]
+			}
+		}
+		mac, err := net.ParseMAC(lladdr)
+		if err != nil {
+			continue
+		}
+		if isNonUnicastMac(mac) {
+			continue
+		}
+		if ip.IsLinkLocalUnicast() {
+			ip = ip.WithZone(dev)
+		}
+		table = append(table, ArpInfo{Ip: ip, Mac: mac.String()})
+	}
+	return table, nil
+}
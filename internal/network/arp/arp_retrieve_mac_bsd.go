@@ -33,6 +33,8 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
+	"os/exec"
+	"strings"
 	"syscall"
 	"unsafe"
 )
@@ -156,3 +158,54 @@ func parseArpTable(buf []byte) ([]ArpInfo, error) {
 
 	return table, nil
 }
+
+/*
+Example "ndp -an" output:
+
+Neighbor                             Linklayer Address  Netif Expire    S Flags
+fe80::1%en0                          1a:90:5:0:1:2        en0 23h59m58s S R
+fd00::15                             c6:c4:d3:0:1:2       en0 permanent R
+fd00::20                             (incomplete)         en0 expired   N
+*/
+
+// Parses the "ndp -an" output (IPv6 neighbor cache)
+// and returns a slice of IP - MAC pairs
+// or (nil, error) in case of an error.
+func RetrieveNeighborTable() ([]ArpInfo, error) {
+	data, err := exec.Command("ndp", "-an").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	table := make([]ArpInfo, 0)
+	for l := range strings.Lines(string(data)) {
+		tokens := strings.Fields(l)
+		if len(tokens) < 3 {
+			continue
+		}
+		ip, err := netip.ParseAddr(tokens[0])
+		if err != nil {
+			continue
+		}
+		mac, err := parseShortMac(tokens[1])
+		if err != nil {
+			continue
+		}
+		if isNonUnicastMac(mac) {
+			continue
+		}
+		table = append(table, ArpInfo{Ip: ip, Mac: mac.String()})
+	}
+	return table, nil
+}
+
+// Parses a MAC address which may have the leading zeros omitted, like 0:11:2:33:44:55.
+func parseShortMac(s string) (net.HardwareAddr, error) {
+	parts := strings.Split(s, ":")
+	for i, p := range parts {
+		if len(p) == 1 {
+			parts[i] = "0" + p
+		}
+	}
+	return net.ParseMAC(strings.Join(parts, ":"))
+}
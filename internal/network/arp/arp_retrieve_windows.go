@@ -66,3 +66,46 @@ func RetrieveArpTable() ([]ArpInfo, error) {
 	}
 	return table, nil
 }
+
+/*
+Example "netsh interface ipv6 show neighbors" output:
+
+Interface 11: Ethernet
+
+Internet Address                              Physical Address   Type
+--------------------------------------------  -----------------  -----------
+fe80::1                                       1a-90-05-00-01-02  Reachable (Router)
+fd00::15                                      c6-c4-d3-00-01-02  Stale
+ff02::1                                       33-33-00-00-00-01  Permanent
+*/
+
+// Parses the "netsh interface ipv6 show neighbors" output
+// and returns a slice of IP - MAC pairs
+// or (nil, error) in case of an error.
+func RetrieveNeighborTable() ([]ArpInfo, error) {
+	data, err := exec.Command("netsh", "interface", "ipv6", "show", "neighbors").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	table := make([]ArpInfo, 0)
+	for l := range strings.Lines(string(data)) {
+		tokens := strings.Fields(l)
+		if len(tokens) < 3 {
+			continue
+		}
+		ip, err := netip.ParseAddr(tokens[0])
+		if err != nil || !ip.Is6() {
+			continue
+		}
+		mac, err := net.ParseMAC(tokens[1])
+		if err != nil {
+			continue
+		}
+		if isNonUnicastMac(mac) {
+			continue
+		}
+		table = append(table, ArpInfo{Ip: ip, Mac: mac.String()})
+	}
+	return table, nil
+}
@@ -0,0 +1,53 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+)
+
+// mDNS port and multicast groups (RFC 6762).
+const MDNSPort = 5353
+
+var (
+	MDNSGroupIPv4 = netip.MustParseAddr("224.0.0.251")
+	MDNSGroupIPv6 = netip.MustParseAddr("ff02::fb")
+)
+
+// DNS-SD services enumeration name (RFC 6763, section 9).
+const ServicesName = "_services._dns-sd._udp.local"
+
+// Multicasts the PTR query for the name from the UDP socket: to the IPv4
+// mDNS group on the default multicast interface for an IPv4 socket,
+// to the IPv6 group on all the multicast interfaces otherwise.
+// The unicast response is requested, and since the source port is not
+// 5353, the responders reply directly to the socket (section 6.7).
+func MulticastQuery(conn *net.UDPConn, name string) error {
+	query, err := NewQuery(0, false, Question{
+		Name:  name,
+		Type:  TypePTR,
+		Class: ClassINET | ClassUnicastResponse,
+	})
+	if err != nil {
+		return err
+	}
+	if conn.LocalAddr().(*net.UDPAddr).IP.To4() != nil {
+		_, err = conn.WriteToUDPAddrPort(query, netip.AddrPortFrom(MDNSGroupIPv4, MDNSPort))
+		return err
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 ||
+			iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		dst := netip.AddrPortFrom(MDNSGroupIPv6.WithZone(iface.Name), MDNSPort)
+		_, err = conn.WriteToUDPAddrPort(query, dst)
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,347 @@
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/netip"
+	"strings"
+)
+
+/*
+	Minimal DNS message encoding and decoding (RFC 1035), enough
+	to talk to unicast DNS, mDNS and LLMNR responders.
+
+	HEADER:
+	ID       (2 bytes)  Transaction ID
+	Flags    (2 bytes)  QR, Opcode, AA, TC, RD, RA, Z, RCODE
+	QDCOUNT  (2 bytes)  Number of questions
+	ANCOUNT  (2 bytes)  Number of answers
+	NSCOUNT  (2 bytes)  Number of authority records
+	ARCOUNT  (2 bytes)  Number of additional records
+	12 bytes total
+
+	QUESTION: NAME, TYPE (2 bytes), CLASS (2 bytes)
+
+	RESOURCE RECORD: NAME, TYPE (2 bytes), CLASS (2 bytes),
+	TTL (4 bytes), RDLENGTH (2 bytes), RDATA (RDLENGTH bytes)
+
+	The names are sequences of length-prefixed labels terminated
+	by a zero byte, or a pointer (two bytes with the high bits set)
+	to a name elsewhere in the message.
+*/
+
+// Resource record types.
+const (
+	TypeA    uint16 = 1
+	TypePTR  uint16 = 12
+	TypeTXT  uint16 = 16
+	TypeAAAA uint16 = 28
+	TypeSRV  uint16 = 33
+	TypeANY  uint16 = 255
+)
+
+// Internet class.
+const ClassINET uint16 = 1
+
+// Requests a unicast response to an mDNS query (RFC 6762, section 5.4),
+// in the answers it means the record set is unique (cache flush bit).
+const ClassUnicastResponse uint16 = 0x8000
+
+// Header flags.
+const (
+	FlagResponse      uint16 = 0x8000
+	FlagAuthoritative uint16 = 0x0400
+	FlagRecursion     uint16 = 0x0100 // recursion desired
+)
+
+// Response codes.
+const (
+	RCodeSuccess   = 0
+	RCodeNameError = 3 // the name doesn't exist
+	RCodeNotImpl   = 4
+	RCodeRefused   = 5
+)
+
+const (
+	rcodeMask        = 0x000f
+	headerLen        = 12
+	maxNameLen       = 253
+	maxLabelLen      = 63
+	maxCompressHops  = 32
+	compressionFlags = 0xc0
+)
+
+var errTruncated = errors.New("truncated DNS message")
+
+type Question struct {
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+type Resource struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+	// Decoded data, depending on the record type
+	Target string     // PTR and SRV
+	Addr   netip.Addr // A and AAAA
+	Port   uint16     // SRV
+	Text   []string   // TXT
+	// Raw data of the other record types
+	Data []byte
+}
+
+type Message struct {
+	ID          uint16
+	Flags       uint16
+	Questions   []Question
+	Answers     []Resource
+	Authorities []Resource
+	Additionals []Resource
+}
+
+// Returns the response code of the message.
+func (m *Message) RCode() int {
+	return int(m.Flags & rcodeMask)
+}
+
+// Builds a query message with the questions.
+// The recursion desired flag is set if recursive is true.
+func NewQuery(id uint16, recursive bool, questions ...Question) ([]byte, error) {
+	m := &Message{ID: id, Questions: questions}
+	if recursive {
+		m.Flags = FlagRecursion
+	}
+	return m.Pack()
+}
+
+// Encodes the message in the wire format, the names are not compressed.
+func (m *Message) Pack() ([]byte, error) {
+	msg := make([]byte, headerLen, 512)
+	binary.BigEndian.PutUint16(msg[0:], m.ID)
+	binary.BigEndian.PutUint16(msg[2:], m.Flags)
+	binary.BigEndian.PutUint16(msg[4:], uint16(len(m.Questions)))
+	binary.BigEndian.PutUint16(msg[6:], uint16(len(m.Answers)))
+	binary.BigEndian.PutUint16(msg[8:], uint16(len(m.Authorities)))
+	binary.BigEndian.PutUint16(msg[10:], uint16(len(m.Additionals)))
+	var err error
+	for _, q := range m.Questions {
+		msg, err = appendName(msg, q.Name)
+		if err != nil {
+			return nil, err
+		}
+		msg = binary.BigEndian.AppendUint16(msg, q.Type)
+		msg = binary.BigEndian.AppendUint16(msg, q.Class)
+	}
+	for _, section := range [][]Resource{m.Answers, m.Authorities, m.Additionals} {
+		for i := range section {
+			msg, err = appendResource(msg, &section[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return msg, nil
+}
+
+func appendResource(msg []byte, r *Resource) ([]byte, error) {
+	msg, err := appendName(msg, r.Name)
+	if err != nil {
+		return nil, err
+	}
+	msg = binary.BigEndian.AppendUint16(msg, r.Type)
+	msg = binary.BigEndian.AppendUint16(msg, r.Class)
+	msg = binary.BigEndian.AppendUint32(msg, r.TTL)
+	// reserve RDLENGTH
+	lenOffset := len(msg)
+	msg = append(msg, 0, 0)
+	switch r.Type {
+	case TypeA, TypeAAAA:
+		msg = append(msg, r.Addr.AsSlice()...)
+	case TypePTR:
+		msg, err = appendName(msg, r.Target)
+	case TypeSRV:
+		msg = append(msg, 0, 0, 0, 0) // priority and weight
+		msg = binary.BigEndian.AppendUint16(msg, r.Port)
+		msg, err = appendName(msg, r.Target)
+	case TypeTXT:
+		for _, t := range r.Text {
+			if len(t) > 255 {
+				return nil, errors.New("TXT string is too long")
+			}
+			msg = append(msg, byte(len(t)))
+			msg = append(msg, t...)
+		}
+	default:
+		msg = append(msg, r.Data...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint16(msg[lenOffset:], uint16(len(msg)-lenOffset-2))
+	return msg, nil
+}
+
+// Appends the domain name in the uncompressed wire format.
+func appendName(msg []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if len(name) > maxNameLen {
+		return nil, errors.New("domain name is too long")
+	}
+	if len(name) > 0 {
+		for label := range strings.SplitSeq(name, ".") {
+			if len(label) == 0 || len(label) > maxLabelLen {
+				return nil, errors.New("invalid domain name label")
+			}
+			msg = append(msg, byte(len(label)))
+			msg = append(msg, label...)
+		}
+	}
+	return append(msg, 0), nil
+}
+
+// Decodes the message from the wire format.
+func Parse(msg []byte) (*Message, error) {
+	if len(msg) < headerLen {
+		return nil, errTruncated
+	}
+	m := &Message{
+		ID:    binary.BigEndian.Uint16(msg[0:]),
+		Flags: binary.BigEndian.Uint16(msg[2:]),
+	}
+	qdCount := int(binary.BigEndian.Uint16(msg[4:]))
+	counts := [3]int{
+		int(binary.BigEndian.Uint16(msg[6:])),
+		int(binary.BigEndian.Uint16(msg[8:])),
+		int(binary.BigEndian.Uint16(msg[10:])),
+	}
+	offset := headerLen
+	for range qdCount {
+		name, next, err := readName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		if next+4 > len(msg) {
+			return nil, errTruncated
+		}
+		m.Questions = append(m.Questions, Question{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(msg[next:]),
+			Class: binary.BigEndian.Uint16(msg[next+2:]),
+		})
+		offset = next + 4
+	}
+	sections := [3]*[]Resource{&m.Answers, &m.Authorities, &m.Additionals}
+	for i, section := range sections {
+		for range counts[i] {
+			r, next, err := readResource(msg, offset)
+			if err != nil {
+				return nil, err
+			}
+			*section = append(*section, r)
+			offset = next
+		}
+	}
+	return m, nil
+}
+
+func readResource(msg []byte, offset int) (Resource, int, error) {
+	var r Resource
+	name, offset, err := readName(msg, offset)
+	if err != nil {
+		return r, 0, err
+	}
+	if offset+10 > len(msg) {
+		return r, 0, errTruncated
+	}
+	r.Name = name
+	r.Type = binary.BigEndian.Uint16(msg[offset:])
+	r.Class = binary.BigEndian.Uint16(msg[offset+2:])
+	r.TTL = binary.BigEndian.Uint32(msg[offset+4:])
+	length := int(binary.BigEndian.Uint16(msg[offset+8:]))
+	offset += 10
+	end := offset + length
+	if end > len(msg) {
+		return r, 0, errTruncated
+	}
+	data := msg[offset:end]
+	switch r.Type {
+	case TypeA, TypeAAAA:
+		addr, ok := netip.AddrFromSlice(data)
+		if !ok {
+			return r, 0, errors.New("invalid address record")
+		}
+		r.Addr = addr
+	case TypePTR:
+		r.Target, _, err = readName(msg, offset)
+	case TypeSRV:
+		if length < 7 {
+			return r, 0, errTruncated
+		}
+		r.Port = binary.BigEndian.Uint16(data[4:])
+		r.Target, _, err = readName(msg, offset+6)
+	case TypeTXT:
+		for i := 0; i < len(data); {
+			n := int(data[i])
+			if i+1+n > len(data) {
+				return r, 0, errTruncated
+			}
+			r.Text = append(r.Text, string(data[i+1:i+1+n]))
+			i += 1 + n
+		}
+	default:
+		r.Data = data
+	}
+	if err != nil {
+		return r, 0, err
+	}
+	return r, end, nil
+}
+
+// Reads the possibly compressed name at the offset. Returns the name
+// without the trailing dot and the offset following the name.
+func readName(msg []byte, offset int) (string, int, error) {
+	var name strings.Builder
+	next := -1
+	for hops := 0; ; {
+		if offset >= len(msg) {
+			return "", 0, errTruncated
+		}
+		n := int(msg[offset])
+		switch {
+		case n == 0:
+			if next < 0 {
+				next = offset + 1
+			}
+			return name.String(), next, nil
+		case n&compressionFlags == compressionFlags:
+			if offset+1 >= len(msg) {
+				return "", 0, errTruncated
+			}
+			hops++
+			if hops > maxCompressHops {
+				return "", 0, errors.New("too many compression pointers")
+			}
+			if next < 0 {
+				next = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(msg[offset:]) & 0x3fff)
+		case n > maxLabelLen:
+			return "", 0, errors.New("invalid domain name label")
+		default:
+			if offset+1+n > len(msg) {
+				return "", 0, errTruncated
+			}
+			if name.Len() > 0 {
+				name.WriteByte('.')
+			}
+			name.Write(msg[offset+1 : offset+1+n])
+			if name.Len() > maxNameLen {
+				return "", 0, errors.New("domain name is too long")
+			}
+			offset += 1 + n
+		}
+	}
+}
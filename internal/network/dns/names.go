@@ -0,0 +1,29 @@
+package dns
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Returns the reverse lookup domain name of the address,
+// e.g. 5.1.168.192.in-addr.arpa or the nibble format under ip6.arpa.
+func ReverseName(addr netip.Addr) string {
+	addr = addr.Unmap()
+	var b strings.Builder
+	if addr.Is4() {
+		ip := addr.As4()
+		fmt.Fprintf(&b, "%d.%d.%d.%d.in-addr.arpa", ip[3], ip[2], ip[1], ip[0])
+		return b.String()
+	}
+	ip := addr.As16()
+	const hexDigits = "0123456789abcdef"
+	for i := len(ip) - 1; i >= 0; i-- {
+		b.WriteByte(hexDigits[ip[i]&0x0f])
+		b.WriteByte('.')
+		b.WriteByte(hexDigits[ip[i]>>4])
+		b.WriteByte('.')
+	}
+	b.WriteString("ip6.arpa")
+	return b.String()
+}
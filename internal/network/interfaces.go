@@ -0,0 +1,64 @@
+package network
+
+import (
+	"net"
+	"net/netip"
+)
+
+// Network attached to a local interface.
+type LocalSubnet struct {
+	Interface string
+	Prefix    netip.Prefix
+}
+
+// Returns the subnets of the local interfaces which are up and running,
+// loopback excluded. IPv6 link-local subnets are skipped.
+func GetLocalSubnets() ([]LocalSubnet, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	subnets := []LocalSubnet{}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagRunning == 0 ||
+			iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip, ok := netip.AddrFromSlice(ipNet.IP)
+			if !ok {
+				continue
+			}
+			ip = ip.Unmap()
+			if ip.Is6() && ip.IsLinkLocalUnicast() {
+				continue
+			}
+			bits, _ := ipNet.Mask.Size()
+			subnets = append(subnets, LocalSubnet{
+				Interface: iface.Name,
+				Prefix:    netip.PrefixFrom(ip, bits).Masked(),
+			})
+		}
+	}
+	return subnets, nil
+}
+
+// Returns the name of the interface attached to the subnet
+// containing the address, or an empty string if there's none.
+func GetInterfaceName(subnets []LocalSubnet, addr netip.Addr) string {
+	addr = addr.WithZone("")
+	for _, s := range subnets {
+		if s.Prefix.Contains(addr) {
+			return s.Interface
+		}
+	}
+	return ""
+}
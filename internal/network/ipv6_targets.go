@@ -0,0 +1,210 @@
+package network
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"netscan/internal/network/arp"
+	"netscan/internal/network/dns"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Strategies to pick the probable host addresses
+// in IPv6 prefixes too large to scan every address.
+const (
+	IPv6Neighbors = "neighbors" // addresses in the IPv6 neighbor cache
+	IPv6LowByte   = "lowbyte"   // common manually assigned addresses ::1 to ::ff
+	IPv6EUI64     = "eui64"     // SLAAC addresses derived from the known MACs
+	IPv6MDNS      = "mdns"      // addresses of the mDNS responders
+)
+
+// All the available strategies.
+var IPv6Strategies = []string{IPv6Neighbors, IPv6LowByte, IPv6EUI64, IPv6MDNS}
+
+// Parses a comma-separated list of IPv6 strategies,
+// "all" for all of them or "none" for an empty list.
+func ParseIPv6Strategies(s string) ([]string, error) {
+	strategies := []string{}
+	for item := range strings.SplitSeq(s, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		switch {
+		case item == "all":
+			strategies = append(strategies, IPv6Strategies...)
+		case item == "none" || item == "":
+		case slices.Contains(IPv6Strategies, item):
+			strategies = append(strategies, item)
+		default:
+			return nil, errors.New("unknown IPv6 strategy: " + item)
+		}
+	}
+	slices.Sort(strategies)
+	return slices.Compact(strategies), nil
+}
+
+// Configure the IPv6 targets generator
+type IPv6TargetsOptions struct {
+	Strategies []string
+	// Time to wait for the mDNS replies.
+	Timeout time.Duration
+}
+
+type IPv6TargetsGenerator struct {
+	strategies []string
+	timeout    time.Duration
+	once       sync.Once
+	neighbors  []arp.ArpInfo
+	macs       []interfaceMAC
+	responders []netip.Addr
+}
+
+// MAC address known on the network interface.
+type interfaceMAC struct {
+	mac   net.HardwareAddr
+	iface string // empty if unknown
+}
+
+// This generator picks the probable host addresses
+// in large IPv6 prefixes using the configured strategies.
+// The local caches are loaded and the mDNS responders
+// are queried once, on the first use.
+func NewIPv6TargetsGenerator(options *IPv6TargetsOptions) *IPv6TargetsGenerator {
+	return &IPv6TargetsGenerator{
+		strategies: options.Strategies,
+		timeout:    options.Timeout,
+	}
+}
+
+// Returns the candidate host addresses in the prefix, may contain duplicates.
+// Link-local addresses are zoned with the interface name; the ones
+// with unknown interface are skipped, since they can't be dialed.
+func (g *IPv6TargetsGenerator) Generate(prefix netip.Prefix) []netip.Addr {
+	g.once.Do(g.collect)
+	prefix = prefix.Masked()
+	// the interface identifiers are the last 64 bits
+	hasIID := prefix.Bits() <= 64
+	addrs := []netip.Addr{}
+	add := func(addr netip.Addr) {
+		if addr.IsLinkLocalUnicast() && len(addr.Zone()) == 0 {
+			return
+		}
+		if prefix.Contains(addr.WithZone("")) {
+			addrs = append(addrs, addr)
+		}
+	}
+	for _, s := range g.strategies {
+		switch s {
+		case IPv6Neighbors:
+			for _, n := range g.neighbors {
+				add(n.Ip)
+				// the same interface identifier is often used for all prefixes
+				if hasIID && n.Ip.IsLinkLocalUnicast() {
+					add(withInterfaceID(prefix, n.Ip))
+				}
+			}
+		case IPv6LowByte:
+			for i := range uint64(0xff) {
+				add(addOffset(prefix.Addr(), i+1))
+			}
+		case IPv6EUI64:
+			if !hasIID {
+				continue
+			}
+			for _, m := range g.macs {
+				add(withInterfaceID(prefix, eui64Addr(m.mac, m.iface)))
+			}
+		case IPv6MDNS:
+			for _, r := range g.responders {
+				add(r)
+				if hasIID && r.IsLinkLocalUnicast() {
+					add(withInterfaceID(prefix, r))
+				}
+			}
+		}
+	}
+	return addrs
+}
+
+// Loads the data used by the strategies.
+func (g *IPv6TargetsGenerator) collect() {
+	if slices.Contains(g.strategies, IPv6Neighbors) || slices.Contains(g.strategies, IPv6EUI64) {
+		g.neighbors, _ = arp.RetrieveNeighborTable()
+	}
+	if slices.Contains(g.strategies, IPv6EUI64) {
+		table, _ := arp.RetrieveArpTable()
+		subnets, _ := GetLocalSubnets()
+		for _, info := range slices.Concat(table, g.neighbors) {
+			mac, err := net.ParseMAC(info.Mac)
+			if err != nil || len(mac) != 6 {
+				continue
+			}
+			iface := info.Ip.Zone()
+			if len(iface) == 0 {
+				iface = GetInterfaceName(subnets, info.Ip)
+			}
+			g.macs = append(g.macs, interfaceMAC{mac, iface})
+		}
+	}
+	if slices.Contains(g.strategies, IPv6MDNS) {
+		g.responders = queryMDNSResponders(g.timeout)
+	}
+}
+
+// Returns the address with the prefix network part
+// and the interface identifier (last 64 bits) of the iid address.
+// A link-local result keeps the zone of the iid address.
+func withInterfaceID(prefix netip.Prefix, iid netip.Addr) netip.Addr {
+	b := prefix.Addr().As16()
+	id := iid.As16()
+	copy(b[8:], id[8:])
+	addr := netip.AddrFrom16(b)
+	if addr.IsLinkLocalUnicast() {
+		return addr.WithZone(iid.Zone())
+	}
+	return addr
+}
+
+// Returns the link-local address on the interface with the modified
+// EUI-64 interface identifier derived from the MAC (RFC 4291, appendix A).
+func eui64Addr(mac net.HardwareAddr, iface string) netip.Addr {
+	b := [16]byte{0xfe, 0x80}
+	b[8] = mac[0] ^ 0x02 // flip the universal/local bit
+	b[9] = mac[1]
+	b[10] = mac[2]
+	b[11] = 0xff
+	b[12] = 0xfe
+	b[13] = mac[3]
+	b[14] = mac[4]
+	b[15] = mac[5]
+	return netip.AddrFrom16(b).WithZone(iface)
+}
+
+// Sends the DNS-SD services enumeration query to the IPv6 mDNS group
+// on all the multicast interfaces and returns the addresses
+// of the hosts replied within the timeout.
+func queryMDNSResponders(timeout time.Duration) []netip.Addr {
+	conn, err := net.ListenUDP("udp6", &net.UDPAddr{})
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+	// some of the interfaces may fail, the others are still queried
+	dns.MulticastQuery(conn, dns.ServicesName)
+
+	responders := []netip.Addr{}
+	buf := make([]byte, 9000)
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	for {
+		_, src, err := conn.ReadFromUDPAddrPort(buf)
+		if err != nil {
+			break
+		}
+		addr := src.Addr().Unmap()
+		if addr.Is6() && !slices.Contains(responders, addr) {
+			responders = append(responders, addr)
+		}
+	}
+	return responders
+}
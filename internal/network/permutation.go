@@ -0,0 +1,65 @@
+package network
+
+/*
+	Pseudo-random permutation of the integers [0, n), used to iterate
+	an address range in random order without materializing it.
+
+	A balanced Feistel network over the smallest even number of bits
+	covering n is a bijection on [0, 2^bits); the values >= n are
+	re-encrypted until they fall into the range ("cycle walking").
+	Since 2^bits < 4n, it takes less than 4 rounds on average.
+*/
+
+const feistelRounds = 4
+
+type permutation struct {
+	n        uint64
+	halfBits uint
+	halfMask uint64
+	keys     [feistelRounds]uint64
+}
+
+// Returns a permutation of [0, n) determined by the seed.
+func newPermutation(n uint64, seed uint64) *permutation {
+	halfBits := uint(1)
+	for halfBits < 32 && n > 1<<(2*halfBits) {
+		halfBits++
+	}
+	p := &permutation{
+		n:        n,
+		halfBits: halfBits,
+		halfMask: 1<<halfBits - 1,
+	}
+	state := seed
+	for i := range p.keys {
+		state = splitMix64(state)
+		p.keys[i] = state
+	}
+	return p
+}
+
+// Returns the i-th element of the permutation, i must be less than n.
+func (p *permutation) At(i uint64) uint64 {
+	x := p.encrypt(i)
+	for x >= p.n {
+		x = p.encrypt(x)
+	}
+	return x
+}
+
+func (p *permutation) encrypt(x uint64) uint64 {
+	left := x >> p.halfBits
+	right := x & p.halfMask
+	for _, k := range p.keys {
+		left, right = right, left^(splitMix64(right^k)&p.halfMask)
+	}
+	return left<<p.halfBits | right
+}
+
+// SplitMix64 finalizer, a fast well-distributed hash function.
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,78 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ports scanned by the TCP scanner unless specified otherwise.
+var DefaultTCPPorts = []uint16{80, 443, 22, 445, 3389}
+
+// Parses TCP ports list specification.
+// Accepts comma-separated ports, ranges and preset names,
+// e.g. "22,80,8000-8100" or "top100,8080";
+// a single "-" means all the ports 1-65535.
+// The order of the ports is preserved, duplicates are removed.
+//
+// Returns:
+//   - the ports list or nil on error;
+//   - error value or nil on success.
+func ParsePorts(s string) ([]uint16, error) {
+	s = strings.TrimSpace(s)
+	if s == "-" {
+		s = "1-65535"
+	}
+	if len(s) == 0 {
+		return nil, errors.New("empty ports list")
+	}
+	result := []uint16{}
+	seen := make(map[uint16]bool)
+	add := func(p uint16) {
+		if !seen[p] {
+			seen[p] = true
+			result = append(result, p)
+		}
+	}
+	for token := range strings.SplitSeq(s, ",") {
+		token = strings.TrimSpace(token)
+		if preset, ok := portPresets[strings.ToLower(token)]; ok {
+			ports, err := ParsePorts(preset)
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range ports {
+				add(p)
+			}
+			continue
+		}
+		first, last, isRange := strings.Cut(token, "-")
+		from, err := parsePort(first)
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if isRange {
+			to, err = parsePort(last)
+			if err != nil {
+				return nil, err
+			}
+			if to < from {
+				return nil, fmt.Errorf("invalid ports range %q", token)
+			}
+		}
+		for p := uint32(from); p <= uint32(to); p++ {
+			add(uint16(p))
+		}
+	}
+	return result, nil
+}
+
+func parsePort(s string) (uint16, error) {
+	p, err := strconv.ParseUint(strings.TrimSpace(s), 10, 16)
+	if err != nil || p == 0 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return uint16(p), nil
+}
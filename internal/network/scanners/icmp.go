@@ -0,0 +1,79 @@
+package scanners
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+/*
+	ICMP Echo Request/Reply message format (RFC 792, RFC 4443 for ICMPv6):
+
+	TYPE        (1 byte)   8 for Echo Request, 0 for Echo Reply;
+	                       128 and 129 respectively for ICMPv6
+	CODE        (1 byte)   0
+	CHECKSUM    (2 bytes)  one's complement sum of the message;
+	                       ICMPv6 checksum includes IPv6 pseudo-header
+	                       and is always calculated by the kernel
+	IDENTIFIER  (2 bytes)
+	SEQUENCE    (2 bytes)
+	DATA        variable length, echoed back by the target
+*/
+
+const (
+	icmpv4EchoReply   = 0
+	icmpv4EchoRequest = 8
+	icmpv6EchoRequest = 128
+	icmpv6EchoReply   = 129
+	icmpHeaderLength  = 8
+)
+
+// Echo Request/Reply message.
+type icmpEcho struct {
+	Type uint8
+	Code uint8
+	ID   uint16
+	Seq  uint16
+	Data []byte
+}
+
+// Serializes the message calculating the checksum.
+func (m *icmpEcho) Marshal() []byte {
+	b := make([]byte, icmpHeaderLength+len(m.Data))
+	b[0] = m.Type
+	b[1] = m.Code
+	binary.BigEndian.PutUint16(b[4:6], m.ID)
+	binary.BigEndian.PutUint16(b[6:8], m.Seq)
+	copy(b[icmpHeaderLength:], m.Data)
+	binary.BigEndian.PutUint16(b[2:4], icmpChecksum(b))
+	return b
+}
+
+// Parses the ICMP message without IP header.
+// Data field references the provided buffer.
+func parseIcmpEcho(b []byte) (*icmpEcho, error) {
+	if len(b) < icmpHeaderLength {
+		return nil, errors.New("ICMP message too short")
+	}
+	return &icmpEcho{
+		Type: b[0],
+		Code: b[1],
+		ID:   binary.BigEndian.Uint16(b[4:6]),
+		Seq:  binary.BigEndian.Uint16(b[6:8]),
+		Data: b[icmpHeaderLength:],
+	}, nil
+}
+
+// Internet checksum as defined by RFC 1071.
+func icmpChecksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
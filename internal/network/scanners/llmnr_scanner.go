@@ -0,0 +1,69 @@
+package scanners
+
+import (
+	"context"
+	"math/rand/v2"
+	"netscan/internal/network/dns"
+	"netscan/internal/network/throttle"
+	"time"
+)
+
+/*
+	Link-Local Multicast Name Resolution (RFC 4795).
+
+	The messages share the DNS format, except for the flags: the C
+	(conflict), TC (truncation) and T (tentative) bits replace AA, TC
+	and RD. The reverse mapping queries (e.g. 5.1.168.192.in-addr.arpa
+	PTR) are sent by unicast to the responder's port 5355 (section 2.4),
+	which answers with its host name. Windows hosts keep answering LLMNR
+	with NetBIOS over TCP/IP disabled.
+*/
+
+const llmnrPort = 5355
+
+type LLMNRScanner struct {
+	udpQuery
+}
+
+// This scanner sends LLMNR reverse lookup query to each target.
+// Limiter may be nil.
+func NewLLMNRScanner(limiter *throttle.RateLimiter) *LLMNRScanner {
+	return &LLMNRScanner{
+		udpQuery: newUDPQuery(limiter, 512),
+	}
+}
+
+func (s *LLMNRScanner) GetName() string {
+	return "LLMNR"
+}
+
+func (s *LLMNRScanner) ScanTimeout(ctx context.Context, target *TargetInfo, timeout time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		id := uint16(rand.Uint32())
+		query, err := dns.NewQuery(id, false, dns.Question{
+			Name:  dns.ReverseName(target.Address),
+			Type:  dns.TypePTR,
+			Class: dns.ClassINET,
+		})
+		if err != nil {
+			return err
+		}
+		return s.exchange(ctx, target, "udp", llmnrPort, query, timeout, func(response []byte) (bool, error) {
+			msg, err := dns.Parse(response)
+			if err != nil || msg.ID != id || msg.Flags&dns.FlagResponse == 0 {
+				// not a reply to our query
+				return false, nil
+			}
+			for _, r := range msg.Answers {
+				if r.Type == dns.TypePTR && len(r.Target) > 0 {
+					target.LLMNRName = r.Target
+					break
+				}
+			}
+			return true, nil
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package scanners
+
+import (
+	"cmp"
+	"context"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"netscan/internal/network/dns"
+	"netscan/internal/network/throttle"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+	Multicast DNS (RFC 6762) and DNS-based service discovery (RFC 6763).
+
+	Each target is asked for its name with a unicast reverse lookup
+	query (e.g. 5.1.168.192.in-addr.arpa PTR) sent to its port 5353.
+
+	Besides, once per scan, the services are browsed: the services
+	enumeration query (_services._dns-sd._udp.local PTR) is multicast,
+	then every advertised service type (e.g. _ipp._tcp.local PTR)
+	is queried the same way. The responders send the service instances
+	along with their SRV (port and host), TXT (metadata) and address
+	records. Since the queries are sent from a port other than 5353,
+	the responders reply directly to the querier (section 6.7).
+*/
+
+const mdnsDomain = ".local"
+
+type MDNSScanner struct {
+	udpQuery
+	browse  sync.Once
+	browsed chan struct{} // closed when browsing is finished
+	muHosts sync.Mutex
+	hosts   map[netip.Addr]*TargetInfo // discovered by browsing
+}
+
+// This scanner sends mDNS reverse lookup query to each target
+// and browses DNS-SD services advertised on the local network.
+// Limiter may be nil.
+func NewMDNSScanner(limiter *throttle.RateLimiter) *MDNSScanner {
+	return &MDNSScanner{
+		udpQuery: newUDPQuery(limiter, 9000),
+		browsed:  make(chan struct{}),
+		hosts:    make(map[netip.Addr]*TargetInfo),
+	}
+}
+
+func (s *MDNSScanner) GetName() string {
+	return "mDNS"
+}
+
+func (s *MDNSScanner) ScanTimeout(ctx context.Context, target *TargetInfo, timeout time.Duration) error {
+	s.browse.Do(func() {
+		go s.browseServices(ctx, timeout)
+	})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		// the legacy unicast responses repeat the ID and the question
+		// (RFC 6762, section 6.7)
+		id := uint16(rand.Uint32())
+		name := dns.ReverseName(target.Address)
+		query, err := dns.NewQuery(id, false, dns.Question{
+			Name:  name,
+			Type:  dns.TypePTR,
+			Class: dns.ClassINET,
+		})
+		if err != nil {
+			return err
+		}
+		return s.exchange(ctx, target, "udp", dns.MDNSPort, query, timeout, func(response []byte) (bool, error) {
+			msg, err := dns.Parse(response)
+			if err != nil || msg.ID != id || msg.Flags&dns.FlagResponse == 0 ||
+				len(msg.Questions) == 0 || !strings.EqualFold(msg.Questions[0].Name, name) {
+				// not a reply to our query
+				return false, nil
+			}
+			for _, r := range msg.Answers {
+				if r.Type == dns.TypePTR && len(r.Target) > 0 {
+					target.MDNSName = r.Target
+					break
+				}
+			}
+			return true, nil
+		})
+	}
+}
+
+// Returns the hosts which have advertised DNS-SD services,
+// waiting for the browsing to finish if it has started.
+// Only the address, MDNSName and Services fields are set.
+func (s *MDNSScanner) Discovered(ctx context.Context) []*TargetInfo {
+	started := true
+	s.browse.Do(func() {
+		started = false
+	})
+	if started {
+		select {
+		case <-s.browsed:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	s.muHosts.Lock()
+	defer s.muHosts.Unlock()
+	hosts := make([]*TargetInfo, 0, len(s.hosts))
+	for _, h := range s.hosts {
+		slices.SortFunc(h.Services, func(a, b MDNSService) int {
+			return cmp.Or(strings.Compare(a.Type, b.Type), strings.Compare(a.Name, b.Name))
+		})
+		hosts = append(hosts, h)
+	}
+	return hosts
+}
+
+// Multicasts the services enumeration query, then queries every
+// advertised service type, collecting the replies for the timeout
+// after the last query.
+func (s *MDNSScanner) browseServices(ctx context.Context, timeout time.Duration) {
+	defer close(s.browsed)
+	conns := []*net.UDPConn{}
+	for _, network := range []string{"udp4", "udp6"} {
+		conn, err := net.ListenUDP(network, nil)
+		if err == nil {
+			conns = append(conns, conn)
+		}
+	}
+	var wg sync.WaitGroup
+	types := make(chan string)
+	done := make(chan struct{})
+	var muQueried sync.Mutex
+	queried := map[string]bool{}
+	for _, conn := range conns {
+		wg.Go(func() {
+			s.receive(conn, func(serviceType string) {
+				muQueried.Lock()
+				isNew := !queried[serviceType]
+				queried[serviceType] = true
+				muQueried.Unlock()
+				if isNew {
+					select {
+					case types <- serviceType:
+					case <-done:
+					}
+				}
+			})
+		})
+	}
+
+	s.multicast(ctx, conns, dns.ServicesName)
+	idle := time.NewTimer(timeout)
+loop:
+	for {
+		select {
+		case t := <-types:
+			s.multicast(ctx, conns, t)
+			// wait for the replies to the new query
+			idle.Reset(timeout)
+		case <-idle.C:
+			break loop
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(done)
+	for _, conn := range conns {
+		conn.Close()
+	}
+	wg.Wait()
+}
+
+// Sends the PTR query to the mDNS groups from every socket.
+func (s *MDNSScanner) multicast(ctx context.Context, conns []*net.UDPConn, name string) {
+	for _, conn := range conns {
+		if s.limiter.Wait(ctx) != nil {
+			return
+		}
+		// some of the interfaces may fail, the others are still queried
+		dns.MulticastQuery(conn, name)
+	}
+}
+
+// Reads the replies until the connection is closed,
+// calling found for every service type learned.
+func (s *MDNSScanner) receive(conn *net.UDPConn, found func(serviceType string)) {
+	buf := s.bytesPool.Get().([]byte)
+	defer s.bytesPool.Put(buf)
+	for {
+		n, src, err := conn.ReadFromUDPAddrPort(buf)
+		if err != nil {
+			return
+		}
+		msg, err := dns.Parse(buf[:n])
+		if err != nil || msg.Flags&dns.FlagResponse == 0 {
+			continue
+		}
+		hosts, serviceTypes := ParseBrowseResponse(msg, src.Addr().Unmap().WithZone(""))
+		s.muHosts.Lock()
+		for _, h := range hosts {
+			s.addHost(h)
+		}
+		s.muHosts.Unlock()
+		for _, t := range serviceTypes {
+			found(t)
+		}
+	}
+}
+
+// Merges the discovered host info, muHosts must be locked.
+func (s *MDNSScanner) addHost(h *TargetInfo) {
+	host, ok := s.hosts[h.Address]
+	if !ok {
+		s.hosts[h.Address] = h
+		return
+	}
+	host.mergeNames(h)
+}
+
+// Parses the DNS-SD response received from the src address.
+//
+// Returns:
+//   - the responder with its services, followed by the hosts of the address
+//     records, all marked alive, with only the Address, MDNSName and Services
+//     fields set;
+//   - the service types listed in reply to the services enumeration query,
+//     like "_ipp._tcp.local", to be browsed next.
+func ParseBrowseResponse(msg *dns.Message, src netip.Addr) ([]*TargetInfo, []string) {
+	responder := &TargetInfo{Address: src}
+	responder.SetState(HostAlive)
+	hosts := []*TargetInfo{responder}
+	serviceTypes := []string{}
+	records := slices.Concat(msg.Answers, msg.Additionals)
+	for _, r := range records {
+		switch r.Type {
+		case dns.TypePTR:
+			if r.Name == dns.ServicesName {
+				serviceTypes = append(serviceTypes, r.Target)
+				responder.addService(MDNSService{Type: trimLocal(r.Target)})
+			} else if strings.HasPrefix(r.Name, "_") {
+				// service type -> instance
+				responder.addService(splitInstance(r.Target))
+			}
+		case dns.TypeSRV:
+			svc := splitInstance(r.Name)
+			svc.Port = r.Port
+			responder.addService(svc)
+		case dns.TypeTXT:
+			svc := splitInstance(r.Name)
+			svc.TXT = slices.DeleteFunc(slices.Clone(r.Text), func(t string) bool {
+				return len(t) == 0
+			})
+			responder.addService(svc)
+		case dns.TypeA, dns.TypeAAAA:
+			if !strings.HasSuffix(r.Name, mdnsDomain) {
+				continue
+			}
+			addr := r.Addr.Unmap()
+			i := slices.IndexFunc(hosts, func(h *TargetInfo) bool {
+				return h.Address == addr
+			})
+			if i < 0 {
+				host := &TargetInfo{Address: addr}
+				host.SetState(HostAlive)
+				hosts = append(hosts, host)
+				i = len(hosts) - 1
+			}
+			hosts[i].MDNSName = r.Name
+		}
+	}
+	if len(responder.MDNSName) == 0 {
+		// the host name is the SRV target
+		for _, r := range records {
+			if r.Type == dns.TypeSRV && len(r.Target) > 0 {
+				responder.MDNSName = r.Target
+				break
+			}
+		}
+	}
+	return hosts, serviceTypes
+}
+
+// Splits the service instance name, like "Printer._ipp._tcp.local",
+// into the instance name and the service type.
+func splitInstance(name string) MDNSService {
+	name = trimLocal(name)
+	// the service type is the last two labels: _service._proto
+	labels := strings.Split(name, ".")
+	if len(labels) < 3 {
+		return MDNSService{Type: name}
+	}
+	i := len(labels) - 2
+	return MDNSService{
+		Name: strings.Join(labels[:i], "."),
+		Type: strings.Join(labels[i:], "."),
+	}
+}
+
+func trimLocal(name string) string {
+	return strings.TrimSuffix(name, mdnsDomain)
+}
@@ -3,6 +3,7 @@ package scanners
 import (
 	"net/netip"
 	"strconv"
+	"time"
 )
 
 // Represents a network device state.
@@ -29,13 +30,72 @@ func (s HostState) String() string {
 
 // Host scan results.
 type TargetInfo struct {
-	Address   netip.Addr
-	state     HostState
-	Mac       string
-	HostName  string
-	Workgroup string
+	Address     netip.Addr
+	state       HostState
+	Mac         string
+	HostName    string
+	Workgroup   string
+	Interface   string        // local interface attached to the host subnet
+	TargetName  string        // host name given as the scan target
+	DNSName     string        // reverse DNS (PTR) name
+	MDNSName    string        // multicast DNS name, like host.local
+	LLMNRName   string        // link-local multicast name resolution name
+	Services    []MDNSService // DNS-SD services advertised by the host
+	OpenPorts   []uint16      // open TCP ports
+	ClosedPorts []uint16      // TCP ports that refused connection
+	RTT         time.Duration // ICMP echo round-trip time, zero if not measured
 	// whatever else...
 	Comments []string
+	// round-trip time measurements not yet consumed by the estimator
+	rttSamples []time.Duration
+}
+
+// Service advertised via DNS-SD.
+type MDNSService struct {
+	Type string // service type, like _ipp._tcp
+	Name string // service instance name
+	Port uint16
+	TXT  []string // metadata key=value pairs
+}
+
+// Adds the service or fills the missing fields
+// of the known one with the same type and instance name.
+func (t *TargetInfo) addService(svc MDNSService) {
+	for i := range t.Services {
+		s := &t.Services[i]
+		if s.Type != svc.Type {
+			continue
+		}
+		if s.Name != svc.Name && len(s.Name) > 0 {
+			if len(svc.Name) == 0 {
+				// the service type is known already
+				return
+			}
+			continue
+		}
+		if len(svc.Name) > 0 {
+			s.Name = svc.Name
+		}
+		if svc.Port != 0 {
+			s.Port = svc.Port
+		}
+		if svc.TXT != nil {
+			s.TXT = svc.TXT
+		}
+		return
+	}
+	t.Services = append(t.Services, svc)
+}
+
+// Adds the mDNS name, unless known already, and the services
+// of the same host found by other means.
+func (t *TargetInfo) mergeNames(other *TargetInfo) {
+	if len(t.MDNSName) == 0 {
+		t.MDNSName = other.MDNSName
+	}
+	for _, svc := range other.Services {
+		t.addService(svc)
+	}
 }
 
 // Return the most optimistic estimation of the host state.
@@ -56,3 +116,17 @@ func (t *TargetInfo) SetState(s HostState) {
 		return
 	}
 }
+
+// Records a response of the host along with its round-trip time.
+// The measurements are used to adjust the timeouts, and a scanner run
+// without any of them is considered as not answered and may be retried.
+func (t *TargetInfo) AddRTTSample(rtt time.Duration) {
+	t.rttSamples = append(t.rttSamples, rtt)
+}
+
+// Returns the recorded round-trip time measurements and clears them.
+func (t *TargetInfo) TakeRTTSamples() []time.Duration {
+	samples := t.rttSamples
+	t.rttSamples = nil
+	return samples
+}
@@ -6,9 +6,8 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"net"
+	"netscan/internal/network/throttle"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -94,20 +93,14 @@ var msBrwsGroup = []byte{
 }
 
 type NbstatScanner struct {
-	dialer    *net.Dialer
-	bytesPool *sync.Pool
+	udpQuery
 }
 
-func NewNbstatScanner() *NbstatScanner {
+// This scanner sends NetBIOS NBSTAT query.
+// Limiter may be nil.
+func NewNbstatScanner(limiter *throttle.RateLimiter) *NbstatScanner {
 	return &NbstatScanner{
-		dialer: &net.Dialer{
-			KeepAlive: -1,
-		},
-		bytesPool: &sync.Pool{
-			New: func() any {
-				return make([]byte, 512)
-			},
-		},
+		udpQuery: newUDPQuery(limiter, 512),
 	}
 }
 
@@ -123,31 +116,12 @@ func (s *NbstatScanner) ScanTimeout(ctx context.Context, target *TargetInfo, tim
 		if !target.Address.Is4() {
 			return errors.New("NetBIOS NBSTAT is only supported for IPv4")
 		}
-		context, cancel := context.WithTimeout(ctx, timeout)
-		defer cancel()
-		addr := net.JoinHostPort(target.Address.String(), "137")
-		conn, err := s.dialer.DialContext(context, "udp4", addr)
-		if err != nil {
-			return nil
-		}
-		conn.SetDeadline(time.Now().Add(timeout))
-		defer conn.Close()
-		_, err = conn.Write(requestBlobe)
-		if err != nil {
-			return err
-		}
-		buf := s.bytesPool.Get().([]byte)
-		defer s.bytesPool.Put(buf)
-		n, err := conn.Read(buf)
-		if err != nil {
-			return err
-		}
-		target.SetState(HostAlive)
-		if n > 0 {
-			return s.parseNbstatResponse(buf[:n], target)
-		}
-
-		return nil
+		return s.exchange(ctx, target, "udp4", 137, requestBlobe, timeout, func(response []byte) (bool, error) {
+			if len(response) > 0 {
+				return true, s.parseNbstatResponse(response, target)
+			}
+			return true, nil
+		})
 	}
 }
 
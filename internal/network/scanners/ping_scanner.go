@@ -1,9 +1,10 @@
-//go:build !windows
+//go:build !windows && !linux
 
 package scanners
 
 import (
 	"context"
+	"netscan/internal/network/throttle"
 	"time"
 )
 
@@ -12,7 +13,7 @@ type PingScanner struct {
 }
 
 // This scanner performs ping (ICMP echo) scan
-func NewPingScanner() *PingScanner {
+func NewPingScanner(limiter *throttle.RateLimiter) *PingScanner {
 	return &PingScanner{}
 }
 
@@ -0,0 +1,328 @@
+package scanners
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"netscan/internal/network/throttle"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+/*
+	Instead of opening a socket per target, the scanner owns one long-lived
+	ICMP socket per address family, opened on the first use.
+	ScanTimeout registers a waiter keyed by the echo sequence number and
+	queues the request to the sender goroutine, which writes the requests
+	to the socket no faster than pingSendInterval. The receiver goroutine
+	reads all replies and hands them over to the matching waiters.
+*/
+
+var payload = []byte("HELLO-R-U-THERE")
+
+// Minimal interval between two consecutive echo requests.
+const pingSendInterval = 100 * time.Microsecond
+
+// Echo request awaiting the reply.
+type pingWaiter struct {
+	addr  netip.Addr
+	msg   []byte
+	sent  atomic.Int64 // request send time, UnixNano
+	reply chan time.Time
+}
+
+type pingKey struct {
+	is4 bool
+	seq uint16
+}
+
+// Shared socket of one address family.
+type icmpEndpoint struct {
+	conn     net.PacketConn
+	is4      bool
+	isDgram  bool
+	requests chan *pingWaiter
+}
+
+type PingScanner struct {
+	id      uint16 // the identifier is replaced by the kernel for datagram sockets
+	seq     atomic.Uint32
+	limiter *throttle.RateLimiter
+
+	muPending sync.Mutex
+	pending   map[pingKey]*pingWaiter
+
+	muEndpoints sync.Mutex
+	endpoints   map[bool]*icmpEndpoint // keyed by is4
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// This scanner performs ping (ICMP echo) scan
+// Limiter may be nil.
+func NewPingScanner(limiter *throttle.RateLimiter) *PingScanner {
+	return &PingScanner{
+		id:        uint16(rand.Uint32()),
+		limiter:   limiter,
+		pending:   make(map[pingKey]*pingWaiter),
+		endpoints: make(map[bool]*icmpEndpoint),
+		done:      make(chan struct{}),
+	}
+}
+
+func (s *PingScanner) GetName() string {
+	return "ICMP Ping"
+}
+
+func (s *PingScanner) ScanTimeout(ctx context.Context, target *TargetInfo, timeout time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		ip := target.Address.Unmap()
+		ep, err := s.getEndpoint(ip.Is4())
+		if err != nil {
+			return err
+		}
+		err = s.limiter.Wait(ctx)
+		if err != nil {
+			return err
+		}
+
+		// register the waiter before sending, so that the reply isn't missed
+		key, waiter := s.register(ip)
+		defer s.unregister(key)
+		request := &icmpEcho{
+			Type: icmpv4EchoRequest,
+			ID:   s.id,
+			Seq:  key.seq,
+			Data: payload,
+		}
+		if !ep.is4 {
+			request.Type = icmpv6EchoRequest
+		}
+		waiter.msg = request.Marshal()
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case ep.requests <- waiter:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.done:
+			return errors.New("ping scanner closed")
+		case <-timer.C:
+			// the send queue is congested
+			return nil
+		}
+
+		select {
+		case received := <-waiter.reply:
+			target.SetState(HostAlive)
+			target.RTT = received.Sub(time.Unix(0, waiter.sent.Load()))
+			target.AddRTTSample(target.RTT)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.done:
+		case <-timer.C:
+			// no reply; that's not an error
+		}
+		return nil
+	}
+}
+
+// Stops the sender and receiver goroutines and closes the sockets.
+func (s *PingScanner) Close() error {
+	s.muEndpoints.Lock()
+	select {
+	case <-s.done:
+		s.muEndpoints.Unlock()
+		return nil
+	default:
+	}
+	close(s.done)
+	for _, ep := range s.endpoints {
+		ep.conn.Close()
+	}
+	s.muEndpoints.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+// Returns the shared endpoint of the address family,
+// opening the socket and starting its goroutines if needed.
+func (s *PingScanner) getEndpoint(is4 bool) (*icmpEndpoint, error) {
+	s.muEndpoints.Lock()
+	defer s.muEndpoints.Unlock()
+	select {
+	case <-s.done:
+		return nil, errors.New("ping scanner closed")
+	default:
+	}
+	if ep, ok := s.endpoints[is4]; ok {
+		return ep, nil
+	}
+	conn, isDgram, err := listenIcmp(is4)
+	if err != nil {
+		return nil, err
+	}
+	ep := &icmpEndpoint{
+		conn:     conn,
+		is4:      is4,
+		isDgram:  isDgram,
+		requests: make(chan *pingWaiter),
+	}
+	s.endpoints[is4] = ep
+	s.wg.Go(func() { s.send(ep) })
+	s.wg.Go(func() { s.receive(ep) })
+	return ep, nil
+}
+
+// Allocates a sequence number not used by any pending request.
+func (s *PingScanner) register(addr netip.Addr) (pingKey, *pingWaiter) {
+	waiter := &pingWaiter{
+		addr:  addr,
+		reply: make(chan time.Time, 1),
+	}
+	s.muPending.Lock()
+	defer s.muPending.Unlock()
+	for {
+		key := pingKey{
+			is4: addr.Is4(),
+			seq: uint16(s.seq.Add(1)),
+		}
+		if _, ok := s.pending[key]; ok {
+			continue
+		}
+		s.pending[key] = waiter
+		return key, waiter
+	}
+}
+
+func (s *PingScanner) unregister(key pingKey) {
+	s.muPending.Lock()
+	delete(s.pending, key)
+	s.muPending.Unlock()
+}
+
+// Writes queued echo requests to the socket at a limited pace.
+func (s *PingScanner) send(ep *icmpEndpoint) {
+	var last time.Time
+	for {
+		select {
+		case <-s.done:
+			return
+		case waiter := <-ep.requests:
+			if wait := pingSendInterval - time.Since(last); wait > 0 {
+				time.Sleep(wait)
+			}
+			ip := waiter.addr
+			var dst net.Addr = &net.IPAddr{IP: ip.AsSlice(), Zone: ip.Zone()}
+			if ep.isDgram {
+				dst = &net.UDPAddr{IP: ip.AsSlice(), Zone: ip.Zone()}
+			}
+			last = time.Now()
+			waiter.sent.Store(last.UnixNano())
+			// on failure the waiter just times out
+			ep.conn.WriteTo(waiter.msg, dst)
+		}
+	}
+}
+
+// Reads echo replies and passes them to the matching waiters.
+func (s *PingScanner) receive(ep *icmpEndpoint) {
+	replyType := uint8(icmpv4EchoReply)
+	if !ep.is4 {
+		replyType = icmpv6EchoReply
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := ep.conn.ReadFrom(buf)
+		received := time.Now()
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		reply, err := parseIcmpEcho(buf[:n])
+		if err != nil || reply.Type != replyType {
+			continue
+		}
+		// raw sockets receive replies to all the processes
+		if !ep.isDgram && reply.ID != s.id {
+			continue
+		}
+		s.muPending.Lock()
+		waiter, ok := s.pending[pingKey{is4: ep.is4, seq: reply.Seq}]
+		s.muPending.Unlock()
+		if !ok || !isSameIP(peer, waiter.addr) {
+			continue
+		}
+		select {
+		case waiter.reply <- received:
+		default:
+		}
+	}
+}
+
+// Opens an ICMPv4 or ICMPv6 socket: the unprivileged datagram one
+// if allowed by net.ipv4.ping_group_range sysctl (it's used for both
+// families), or the raw one otherwise.
+// Returns the connection and true if it's a datagram socket.
+func listenIcmp(is4 bool) (net.PacketConn, bool, error) {
+	family, proto := syscall.AF_INET, syscall.IPPROTO_ICMP
+	rawNetwork, rawAddr := "ip4:icmp", "0.0.0.0"
+	if !is4 {
+		family, proto = syscall.AF_INET6, syscall.IPPROTO_ICMPV6
+		rawNetwork, rawAddr = "ip6:ipv6-icmp", "::"
+	}
+	conn, err := listenIcmpDgram(family, proto)
+	if err == nil {
+		return conn, true, nil
+	}
+	// fallback requires CAP_NET_RAW
+	rawConn, rawErr := net.ListenPacket(rawNetwork, rawAddr)
+	if rawErr != nil {
+		return nil, false, fmt.Errorf("ICMP socket not permitted: %w", err)
+	}
+	return rawConn, false, nil
+}
+
+// Opens a non-privileged ICMP datagram ("ping") socket.
+func listenIcmpDgram(family, proto int) (net.PacketConn, error) {
+	fd, err := syscall.Socket(family, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, proto)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	f := os.NewFile(uintptr(fd), "icmp")
+	defer f.Close()
+	// the file descriptor is duplicated
+	return net.FilePacketConn(f)
+}
+
+// Check if the reply came from the expected address.
+func isSameIP(peer net.Addr, addr netip.Addr) bool {
+	var ip net.IP
+	switch a := peer.(type) {
+	case *net.UDPAddr:
+		ip = a.IP
+	case *net.IPAddr:
+		ip = a.IP
+	default:
+		return false
+	}
+	return ip.Equal(addr.AsSlice())
+}
@@ -3,8 +3,8 @@ package scanners
 import (
 	"context"
 	"errors"
-	"fmt"
 	"net/netip"
+	"netscan/internal/network/throttle"
 	"sync"
 	"time"
 	"unsafe"
@@ -39,16 +39,22 @@ var (
 
 	replySize = 256
 
+	// IcmpSendEcho2 error code when no reply is received in time
+	errIPReqTimedOut = windows.Errno(11010)
+
 	payload = []byte("HELLO-R-U-THERE")
 )
 
 type PingScanner struct {
 	bytesPool *sync.Pool
+	limiter   *throttle.RateLimiter
 }
 
-// This scanner performs ping (ICMP echo) scan
-func NewPingScanner() *PingScanner {
+// This scanner performs ping (ICMP echo) scan.
+// Limiter may be nil.
+func NewPingScanner(limiter *throttle.RateLimiter) *PingScanner {
 	return &PingScanner{
+		limiter: limiter,
 		bytesPool: &sync.Pool{
 			New: func() any {
 				return make([]byte, replySize)
@@ -81,6 +87,11 @@ func (s *PingScanner) ScanTimeout(ctx context.Context, target *TargetInfo, timeo
 
 		ip := uint32FromAddr(target.Address)
 
+		err = s.limiter.Wait(ctx)
+		if err != nil {
+			return err
+		}
+
 		r, _, err := procIcmpSendEcho2.Call(
 			h,
 			0,
@@ -92,9 +103,13 @@ func (s *PingScanner) ScanTimeout(ctx context.Context, target *TargetInfo, timeo
 			0,
 			uintptr(unsafe.Pointer(&replyBuf[0])),
 			uintptr(replySize),
-			uintptr(3000),
+			uintptr(timeout.Milliseconds()),
 		)
 		if r == 0 {
+			if errors.Is(err, errIPReqTimedOut) {
+				// no reply; that's not an error, so it may be retried
+				return nil
+			}
 			return err
 		}
 
@@ -102,8 +117,10 @@ func (s *PingScanner) ScanTimeout(ctx context.Context, target *TargetInfo, timeo
 		if reply.Status == 0 {
 			// ping succeeded
 			target.state = HostAlive
-			target.Comments = append(target.Comments,
-				fmt.Sprintf("ICMP Echo RTT %d ms", reply.RoundTripTime))
+			// the round-trip time is in whole milliseconds,
+			// so the faster replies would be reported as 0
+			target.RTT = max(time.Duration(reply.RoundTripTime)*time.Millisecond, time.Millisecond)
+			target.AddRTTSample(target.RTT)
 		}
 
 		return nil
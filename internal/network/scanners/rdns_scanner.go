@@ -0,0 +1,110 @@
+package scanners
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"netscan/internal/network/throttle"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default maximum number of concurrent reverse DNS lookups.
+const DefaultRDNSThreads = 8
+
+// Configure the reverse DNS scanner
+type RDNSScannerOptions struct {
+	// Name server address as host or host:port,
+	// the system resolver is used if empty.
+	Server string
+	// Maximum number of concurrent lookups,
+	// DefaultRDNSThreads if zero.
+	Threads int
+}
+
+type RDNSScanner struct {
+	resolver *net.Resolver
+	threads  *throttle.Semaphore
+	muCache  sync.Mutex
+	cache    map[netip.Addr]string
+}
+
+// This scanner looks up the DNS name (PTR record)
+// of the addresses which have responded to the other scanners,
+// it's run by ScannersManager.Enrich over the final results
+func NewRDNSScanner(options *RDNSScannerOptions) *RDNSScanner {
+	threads := options.Threads
+	if threads <= 0 {
+		threads = DefaultRDNSThreads
+	}
+	resolver := net.DefaultResolver
+	if len(options.Server) > 0 {
+		server := serverAddress(options.Server)
+		dialer := &net.Dialer{}
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return &RDNSScanner{
+		resolver: resolver,
+		threads:  throttle.NewSemaphore(threads),
+		cache:    make(map[netip.Addr]string),
+	}
+}
+
+func (s *RDNSScanner) GetName() string {
+	return "Reverse DNS"
+}
+
+func (s *RDNSScanner) ScanTimeout(ctx context.Context, target *TargetInfo, timeout time.Duration) error {
+	if target.GetState() == HostDead {
+		return nil
+	}
+	name, ok := s.lookupCache(target.Address)
+	if !ok {
+		if err := s.threads.Acquire(ctx); err != nil {
+			return err
+		}
+		defer s.threads.Release()
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		names, err := s.resolver.LookupAddr(ctx, target.Address.String())
+		if err != nil {
+			var dnsErr *net.DNSError
+			if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+				// not cached, may succeed next time
+				return err
+			}
+		}
+		if len(names) > 0 {
+			name = strings.TrimSuffix(names[0], ".")
+		}
+		s.muCache.Lock()
+		s.cache[target.Address] = name
+		s.muCache.Unlock()
+	}
+	target.DNSName = name
+	return nil
+}
+
+// Returns the name server address with the default DNS port
+// if no port is given: host, IPv6 address, [IPv6 address] or host:port.
+func serverAddress(server string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	server = strings.TrimSuffix(strings.TrimPrefix(server, "["), "]")
+	return net.JoinHostPort(server, "53")
+}
+
+func (s *RDNSScanner) lookupCache(addr netip.Addr) (string, bool) {
+	s.muCache.Lock()
+	defer s.muCache.Unlock()
+	name, ok := s.cache[addr]
+	return name, ok
+}
@@ -0,0 +1,104 @@
+package scanners
+
+import (
+	"net/netip"
+	"sync"
+	"time"
+)
+
+/*
+	Round-trip time estimation follows the TCP retransmission timer
+	algorithm (RFC 6298):
+
+	first sample R:  SRTT = R, RTTVAR = R/2
+	next samples:    RTTVAR = 3/4 * RTTVAR + 1/4 * |SRTT - R|
+	                 SRTT = 7/8 * SRTT + 1/8 * R
+	timeout:         RTO = SRTT + 4 * RTTVAR
+
+	The estimation is maintained per subnet (/24 for IPv4, /64 for IPv6),
+	since the hosts behind the same switch/AP tend to respond alike.
+*/
+
+const (
+	// Number of samples required before the timeout is adjusted.
+	adaptiveMinSamples = 3
+	// Lower bound of the adaptive timeout.
+	adaptiveMinTimeout = 50 * time.Millisecond
+	// Upper bound of the adaptive timeout relative to the configured one.
+	adaptiveMaxFactor = 3
+)
+
+type rttStats struct {
+	srtt    time.Duration
+	rttvar  time.Duration
+	samples int
+}
+
+type RTTEstimator struct {
+	mu    sync.Mutex
+	stats map[netip.Prefix]*rttStats
+}
+
+// Maintains smoothed round-trip time per subnet
+// and derives timeouts from it
+func NewRTTEstimator() *RTTEstimator {
+	return &RTTEstimator{
+		stats: make(map[netip.Prefix]*rttStats),
+	}
+}
+
+// Feeds a round-trip time measurement of the host.
+func (e *RTTEstimator) Update(addr netip.Addr, rtt time.Duration) {
+	if rtt <= 0 {
+		return
+	}
+	key := subnetOf(addr)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st, ok := e.stats[key]
+	if !ok {
+		e.stats[key] = &rttStats{
+			srtt:    rtt,
+			rttvar:  rtt / 2,
+			samples: 1,
+		}
+		return
+	}
+	delta := st.srtt - rtt
+	if delta < 0 {
+		delta = -delta
+	}
+	st.rttvar = (3*st.rttvar + delta) / 4
+	st.srtt = (7*st.srtt + rtt) / 8
+	st.samples++
+}
+
+// Returns the timeout for the host adjusted to the observed
+// round-trip times of its subnet, within the bounds derived from
+// the configured timeout; or the configured timeout itself
+// if there's not enough measurements yet.
+func (e *RTTEstimator) Timeout(addr netip.Addr, configured time.Duration) time.Duration {
+	e.mu.Lock()
+	st, ok := e.stats[subnetOf(addr)]
+	if !ok || st.samples < adaptiveMinSamples {
+		e.mu.Unlock()
+		return configured
+	}
+	rto := st.srtt + 4*st.rttvar
+	e.mu.Unlock()
+	return min(max(rto, adaptiveMinTimeout), configured*adaptiveMaxFactor)
+}
+
+// Returns the subnet the address belongs to.
+func subnetOf(addr netip.Addr) netip.Prefix {
+	addr = addr.Unmap()
+	bits := 64
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return netip.PrefixFrom(addr, addr.BitLen())
+	}
+	return prefix
+}
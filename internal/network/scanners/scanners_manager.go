@@ -1,8 +1,15 @@
 package scanners
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"netscan/internal/network/throttle"
+	"slices"
+	"sync"
 	"time"
 )
 
@@ -12,31 +19,112 @@ type Scanner interface {
 	GetName() string
 }
 
+// Default timeout of a single scanner run.
+const DefaultTimeout = 1 * time.Second
+
+// Delay before the first retry, doubled on every next one.
+const retryBackoff = 100 * time.Millisecond
+
+// Implemented by the scanners which find hosts on their own,
+// besides probing the targets.
+type HostsDiscoverer interface {
+	// Returns the hosts found, waiting for the discovery to finish.
+	Discovered(ctx context.Context) []*TargetInfo
+}
+
+// Returned by a scanner when the target has definitely answered
+// negatively (e.g. the port is unreachable), so there's no need to retry.
+var ErrDefinitelyNegative = errors.New("negative response")
+
 // Configure what scanners to include and other options
 type ScannersManagerOptions struct {
 	IncludeTCPScan  bool
 	IncludeICMPPing bool
 	IncludeNbstat   bool
+	IncludeRDNS     bool
+	IncludeMDNS     bool
+	IncludeLLMNR    bool
+	TCPPorts        []uint16
+	TCPDiscovery    bool // stop TCP probing at the first response
+	TCPHostDials    int  // concurrent TCP connection attempts per target
+	DNSServer       string
+	RDNSThreads     int // concurrent reverse DNS lookups
+	// Probes rate limit shared by all the scanners, may be nil
+	Limiter *throttle.RateLimiter
+	// Timeout of all the scanners, DefaultTimeout if zero
+	Timeout time.Duration
+	// Per scanner timeouts, Timeout if zero
+	TCPTimeout    time.Duration
+	NbstatTimeout time.Duration
+	PingTimeout   time.Duration
+	RDNSTimeout   time.Duration
+	MDNSTimeout   time.Duration
+	LLMNRTimeout  time.Duration
+	// Adjust the timeouts to the observed round-trip times
+	AdaptiveTimeout bool
+	// Number of additional attempts when a scanner gets no response
+	Retries int
 	// more scanner types...
 	IsVerbose bool // TODO not implemented yet
 }
 
 type ScannersManager struct {
-	steps    int
-	scanners []Scanner
+	steps          int
+	scanners       []Scanner
+	timeouts       []time.Duration
+	enrichers      []Scanner // run over the final results, see Enrich
+	enrichTimeouts []time.Duration
+	rtt            *RTTEstimator // nil if adaptive timeouts are disabled
+	retries        int
 }
 
 // Returns a configured set of ready to use scanners
 func NewScannersManager(options *ScannersManagerOptions) *ScannersManager {
 	s := &ScannersManager{}
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	add := func(scanner Scanner, t time.Duration) {
+		if t <= 0 {
+			t = timeout
+		}
+		s.scanners = append(s.scanners, scanner)
+		s.timeouts = append(s.timeouts, t)
+	}
 	if options.IncludeTCPScan {
-		s.scanners = append(s.scanners, NewTCPScanner())
+		add(NewTCPScanner(&TCPScannerOptions{
+			Ports:         options.TCPPorts,
+			DiscoveryOnly: options.TCPDiscovery,
+			HostDials:     options.TCPHostDials,
+			Limiter:       options.Limiter,
+		}), options.TCPTimeout)
 	}
 	if options.IncludeICMPPing {
-		s.scanners = append(s.scanners, NewPingScanner())
+		add(NewPingScanner(options.Limiter), options.PingTimeout)
 	}
 	if options.IncludeNbstat {
-		s.scanners = append(s.scanners, NewNbstatScanner())
+		add(NewNbstatScanner(options.Limiter), options.NbstatTimeout)
+	}
+	if options.IncludeMDNS {
+		add(NewMDNSScanner(options.Limiter), options.MDNSTimeout)
+	}
+	if options.IncludeLLMNR {
+		add(NewLLMNRScanner(options.Limiter), options.LLMNRTimeout)
+	}
+	// enrichment of the hosts found by the scanners above;
+	// the lookups don't probe the targets, so they're neither
+	// retried nor adjusted to the round-trip times
+	if options.IncludeRDNS {
+		s.enrichers = append(s.enrichers, NewRDNSScanner(&RDNSScannerOptions{
+			Server:  options.DNSServer,
+			Threads: options.RDNSThreads,
+		}))
+		s.enrichTimeouts = append(s.enrichTimeouts, cmp.Or(options.RDNSTimeout, timeout))
+	}
+	s.retries = max(options.Retries, 0)
+	if options.AdaptiveTimeout {
+		s.rtt = NewRTTEstimator()
 	}
 	s.steps = len(s.scanners)
 	return s
@@ -55,11 +143,130 @@ func (m *ScannersManager) GetScanner(step int) (Scanner, error) {
 	return m.scanners[step], nil
 }
 
+// Get the configured timeout of a scanner by its number
+func (m *ScannersManager) GetTimeout(step int) time.Duration {
+	if step < 0 || step >= len(m.timeouts) {
+		return DefaultTimeout
+	}
+	return m.timeouts[step]
+}
+
+// Runs a scanner by its number against the target
+// with the configured or adaptive timeout.
+// If the target doesn't respond, the run is retried
+// up to the configured number of times with exponential backoff.
+func (m *ScannersManager) Scan(ctx context.Context, step int, target *TargetInfo) error {
+	scanner, err := m.GetScanner(step)
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		timeout := m.GetTimeout(step)
+		if m.rtt != nil {
+			timeout = m.rtt.Timeout(target.Address, timeout)
+		}
+		err = scanner.ScanTimeout(ctx, target, timeout)
+		samples := target.TakeRTTSamples()
+		if m.rtt != nil {
+			for _, rtt := range samples {
+				m.rtt.Update(target.Address, rtt)
+			}
+		}
+		// errors include definitely negative responses
+		// and other cases when a retry makes no sense
+		if err != nil || len(samples) > 0 || attempt >= m.retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryBackoff << attempt):
+		}
+	}
+}
+
+// Runs the enrichment scanners, like reverse DNS lookups, over the final
+// results, so that the hosts found after the scan (ARP cache, mDNS browsing)
+// are enriched too. The dead hosts are skipped.
+// Returns the errors of all the targets joined.
+func (m *ScannersManager) Enrich(ctx context.Context, targets []*TargetInfo) error {
+	var muErrs sync.Mutex
+	var errs []error
+	for i, scanner := range m.enrichers {
+		// the concurrency is limited by the scanner
+		var wg sync.WaitGroup
+		for _, t := range targets {
+			if t.GetState() == HostDead {
+				continue
+			}
+			wg.Go(func() {
+				err := scanner.ScanTimeout(ctx, t, m.enrichTimeouts[i])
+				if err != nil {
+					muErrs.Lock()
+					errs = append(errs, fmt.Errorf("%v: %w", t.Address, err))
+					muErrs.Unlock()
+				}
+			})
+		}
+		wg.Wait()
+	}
+	return errors.Join(errs...)
+}
+
+// Returns the hosts found by the scanners on their own,
+// there may be duplicates and hosts out of the scan range.
+func (m *ScannersManager) Discovered(ctx context.Context) []*TargetInfo {
+	hosts := []*TargetInfo{}
+	for _, s := range m.scanners {
+		if d, ok := s.(HostsDiscoverer); ok {
+			hosts = append(hosts, d.Discovered(ctx)...)
+		}
+	}
+	return hosts
+}
+
+// Merges the hosts found by the scanners on their own into the scanned
+// ones: their mDNS names and services are added, and they're marked alive.
+// Returns the discovered hosts which aren't among the results,
+// with the duplicates merged.
+func MergeDiscovered(results []*TargetInfo, discovered []*TargetInfo) []*TargetInfo {
+	hosts := make(map[netip.Addr]*TargetInfo, len(results))
+	for _, r := range results {
+		hosts[r.Address] = r
+	}
+	added := []*TargetInfo{}
+	for _, d := range discovered {
+		host, ok := hosts[d.Address]
+		if !ok {
+			hosts[d.Address] = d
+			added = append(added, d)
+			continue
+		}
+		host.mergeNames(d)
+		host.SetState(HostAlive)
+	}
+	return added
+}
+
 // Names of all scanners in the set
 func (m *ScannersManager) GetNames() []string {
-	result := make([]string, 0, len(m.scanners))
+	result := make([]string, 0, len(m.scanners)+len(m.enrichers))
 	for _, s := range m.scanners {
 		result = append(result, s.GetName())
 	}
+	for _, s := range m.enrichers {
+		result = append(result, s.GetName())
+	}
 	return result
 }
+
+// Releases the resources held by the scanners, if any
+func (m *ScannersManager) Close() error {
+	var errs []error
+	for _, s := range slices.Concat(m.scanners, m.enrichers) {
+		if c, ok := s.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
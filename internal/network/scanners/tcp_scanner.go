@@ -2,29 +2,72 @@ package scanners
 
 import (
 	"context"
-	"fmt"
 	"net"
+	"netscan/internal/network/throttle"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// Default maximum number of concurrent connection attempts per target.
+const DefaultTCPHostDials = 16
+
+// Result of a single port probe.
+type portState int
+
+const (
+	portFiltered portState = iota // no response
+	portClosed                    // connection refused
+	portOpen                      // connection established
+)
+
+// Configure the TCP scanner
+type TCPScannerOptions struct {
+	Ports []uint16
+	// Stop probing a target at the first response (either connection
+	// established or refused) instead of enumerating all the ports.
+	DiscoveryOnly bool
+	// Maximum number of concurrent connection attempts per target,
+	// DefaultTCPHostDials if zero. The caller should reserve that
+	// many threads per target, see HostThreads.
+	HostDials int
+	// Limits the connection attempts rate, may be nil.
+	Limiter *throttle.RateLimiter
+}
+
 type TCPScanner struct {
-	dialer *net.Dialer
-	ports  []string
+	dialer        *net.Dialer
+	ports         []uint16
+	discoveryOnly bool
+	hostDials     int
+	limiter       *throttle.RateLimiter
 	// configuration fields if needed
 }
 
 // This scanner performs TCP connection attempt
-func NewTCPScanner() *TCPScanner {
+// on each of the provided ports
+func NewTCPScanner(options *TCPScannerOptions) *TCPScanner {
+	hostDials := options.HostDials
+	if hostDials <= 0 {
+		hostDials = DefaultTCPHostDials
+	}
 	return &TCPScanner{
 		dialer: &net.Dialer{
 			KeepAlive: -1,
 		},
-		ports: []string{"80", "443", "22", "445", "3389"},
+		ports:         options.Ports,
+		discoveryOnly: options.DiscoveryOnly,
+		hostDials:     hostDials,
+		limiter:       options.Limiter,
 	}
 }
 
 func (s *TCPScanner) GetName() string {
+	if s.discoveryOnly {
+		return "TCP Discovery"
+	}
 	return "TCP Scan"
 }
 
@@ -33,61 +76,124 @@ func (s *TCPScanner) ScanTimeout(ctx context.Context, target *TargetInfo, timeou
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
-		openPorts := []string{}
-		for _, port := range s.ports {
-			context, cancel := context.WithTimeout(ctx, timeout)
-			defer cancel()
-			addr := net.JoinHostPort(target.Address.String(), port)
-			conn, err := s.dialer.DialContext(context, "tcp", addr)
-			if err != nil {
-				errStr := err.Error()
-				// possible strings:
-				// i/o timeout
-				// connect: host is down
-				// connect: no route to host
-				//
-				// positive detection:
-				// connect: connection refused
-				// target.Comments = append(target.Comments, errStr)
-				/*
-					switch {
-					case strings.Contains(errStr, "refused"):
-						target.SetState(HostAlive)
-					// case strings.Contains(errStr, "timeout"): target.SetState(HostUnknown)
-					case strings.Contains(errStr, "no route") ||
-						strings.Contains(errStr, "down") ||
-						strings.Contains(errStr, "unreachable"):
-						target.SetState(HostDead)
-					default:
-						target.SetState(HostUnknown)
-					}
-				*/
-				if strings.Contains(errStr, "refused") {
-					target.SetState(HostAlive)
-					// break here makes scan faster, but prevents open ports scanning
-					// TODO make a command switch
-					//break
-				}
-			} else {
-				// TODO fingerprint target
-				// TODO banner grabbing
-				openPorts = append(openPorts, fmt.Sprintf("%s/TCP", port))
-				conn.Close()
-				target.SetState(HostAlive)
-				// break here makes scan faster, but prevents open ports scanning
-				// TODO make a command switch
-				//break
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		results := make(chan probeResult)
+		go s.dispatch(ctx, target, timeout, results)
+
+		// drain the channel until all the dialers finish
+		found := false
+		for r := range results {
+			if r.state == portFiltered {
+				continue
 			}
-			/*
-				if target.GetState() == HostAlive {
-					break
+			if s.discoveryOnly {
+				// the first response is enough
+				if found {
+					continue
 				}
-			*/
-		}
-		if len(openPorts) > 0 {
-			target.Comments = append(target.Comments,
-				fmt.Sprintf("%s open", strings.Join(openPorts, ", ")))
+				found = true
+				cancel()
+			}
+			// TODO fingerprint target
+			// TODO banner grabbing
+			s.recordPort(target, r.port, r.state)
+			target.AddRTTSample(r.rtt)
 		}
+		slices.Sort(target.OpenPorts)
+		slices.Sort(target.ClosedPorts)
 	}
 	return nil
 }
+
+type probeResult struct {
+	port  uint16
+	state portState
+	rtt   time.Duration // connection establishment (or refusal) time
+}
+
+// Returns the number of threads a target takes while its ports are
+// probed: the concurrent connection attempts, no more than the ports
+// count and the threads limit. The workers pool should be sized as
+// threads / HostThreads, so that the attempts don't exceed the limit.
+func HostThreads(hostDials int, ports int, threads int) int {
+	if hostDials <= 0 {
+		hostDials = DefaultTCPHostDials
+	}
+	return max(min(hostDials, ports, threads), 1)
+}
+
+// Runs the port probes concurrently, up to hostDials at once,
+// sending the results to the channel and closing it when done.
+// The threads are reserved for the target by the caller.
+func (s *TCPScanner) dispatch(ctx context.Context, target *TargetInfo, timeout time.Duration, results chan<- probeResult) {
+	defer close(results)
+	dials := throttle.NewSemaphore(s.hostDials)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for _, port := range s.ports {
+		if dials.Acquire(ctx) != nil {
+			return
+		}
+		wg.Go(func() {
+			defer dials.Release()
+			state, rtt := s.probe(ctx, target, port, timeout)
+			results <- probeResult{port, state, rtt}
+		})
+	}
+}
+
+// Attempts to connect to the target port. Returns the port state
+// and the connection time, not including the rate limiter delay.
+func (s *TCPScanner) probe(ctx context.Context, target *TargetInfo, port uint16, timeout time.Duration) (portState, time.Duration) {
+	if s.limiter.Wait(ctx) != nil {
+		return portFiltered, 0
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	addr := net.JoinHostPort(target.Address.String(), strconv.Itoa(int(port)))
+	start := time.Now()
+	conn, err := s.dialer.DialContext(ctx, "tcp", addr)
+	rtt := time.Since(start)
+	if err != nil {
+		errStr := err.Error()
+		// possible strings:
+		// i/o timeout
+		// connect: host is down
+		// connect: no route to host
+		//
+		// positive detection:
+		// connect: connection refused
+		/*
+			switch {
+			case strings.Contains(errStr, "refused"):
+				target.SetState(HostAlive)
+			// case strings.Contains(errStr, "timeout"): target.SetState(HostUnknown)
+			case strings.Contains(errStr, "no route") ||
+				strings.Contains(errStr, "down") ||
+				strings.Contains(errStr, "unreachable"):
+				target.SetState(HostDead)
+			default:
+				target.SetState(HostUnknown)
+			}
+		*/
+		if strings.Contains(errStr, "refused") {
+			return portClosed, rtt
+		}
+		return portFiltered, rtt
+	}
+	conn.Close()
+	return portOpen, rtt
+}
+
+// Saves the port probe result to the target.
+func (s *TCPScanner) recordPort(target *TargetInfo, port uint16, state portState) {
+	switch state {
+	case portOpen:
+		target.OpenPorts = append(target.OpenPorts, port)
+		target.SetState(HostAlive)
+	case portClosed:
+		target.ClosedPorts = append(target.ClosedPorts, port)
+		target.SetState(HostAlive)
+	}
+}
@@ -0,0 +1,91 @@
+package scanners
+
+import (
+	"context"
+	"errors"
+	"net"
+	"netscan/internal/network/throttle"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Request-response exchange over UDP with a service on the target,
+// shared by the NBSTAT, mDNS and LLMNR scanners.
+type udpQuery struct {
+	dialer    *net.Dialer
+	bytesPool *sync.Pool
+	limiter   *throttle.RateLimiter
+}
+
+// Limiter may be nil, the responses up to bufferSize bytes are read.
+func newUDPQuery(limiter *throttle.RateLimiter, bufferSize int) udpQuery {
+	return udpQuery{
+		limiter: limiter,
+		dialer: &net.Dialer{
+			KeepAlive: -1,
+		},
+		bytesPool: &sync.Pool{
+			New: func() any {
+				return make([]byte, bufferSize)
+			},
+		},
+	}
+}
+
+// Sends the request to the port of the target and waits for the response
+// for the timeout. Every datagram received is passed to the handler until
+// it accepts one; the datagram is only valid during the call.
+// Once a response is accepted, the target is marked alive with the
+// round-trip time sample, even if the handler returns an error.
+//
+// Returns:
+//   - nil if there's no response, that's not an error;
+//   - ErrDefinitelyNegative if the port is unreachable;
+//   - the handler error or other errors.
+func (q *udpQuery) exchange(ctx context.Context, target *TargetInfo, network string, port int,
+	request []byte, timeout time.Duration, handle func(response []byte) (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	addr := net.JoinHostPort(target.Address.String(), strconv.Itoa(port))
+	conn, err := q.dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	defer conn.Close()
+	err = q.limiter.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	_, err = conn.Write(request)
+	if err != nil {
+		return err
+	}
+	buf := q.bytesPool.Get().([]byte)
+	defer q.bytesPool.Put(buf)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				// no answer; that's not an error
+				return nil
+			}
+			if errors.Is(err, syscall.ECONNREFUSED) {
+				// ICMP port unreachable received
+				return ErrDefinitelyNegative
+			}
+			return err
+		}
+		accepted, err := handle(buf[:n])
+		if !accepted {
+			continue
+		}
+		target.SetState(HostAlive)
+		target.AddRTTSample(time.Since(start))
+		return err
+	}
+}
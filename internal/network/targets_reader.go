@@ -0,0 +1,25 @@
+package network
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Reads a list of target expressions, such as accepted by
+// AddrParser.AddTarget, one or more per line separated by
+// whitespace or commas. Everything after # is a comment.
+func ReadTargets(r io.Reader) ([]string, error) {
+	targets := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		targets = append(targets, strings.FieldsFunc(line, func(c rune) bool {
+			return c == ',' || c == ' ' || c == '\t' || c == '\r'
+		})...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
@@ -0,0 +1,60 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Token bucket limiting the rate of operations (e.g. packets sent).
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration // time to refill one token
+	burst    float64
+	tokens   float64
+	last     time.Time
+}
+
+// Returns a limiter allowing rate operations per second on average
+// and up to burst operations at once. Burst below 1 is treated as 1.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	b := float64(max(burst, 1))
+	return &RateLimiter{
+		interval: time.Duration(float64(time.Second) / rate),
+		burst:    b,
+		tokens:   b,
+		last:     time.Now(),
+	}
+}
+
+// Blocks until the operation is allowed or the context is done.
+// A nil limiter never blocks.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+float64(now.Sub(l.last))/float64(l.interval))
+	l.last = now
+	// reserve a token, possibly going into debt
+	l.tokens--
+	wait := time.Duration(-l.tokens * float64(l.interval))
+	l.mu.Unlock()
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// give the reserved token back
+		l.mu.Lock()
+		l.tokens = min(l.burst, l.tokens+1)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
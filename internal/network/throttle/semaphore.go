@@ -0,0 +1,30 @@
+package throttle
+
+import "context"
+
+// Counting semaphore limiting the number of concurrently running operations.
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// Returns a semaphore with n slots.
+func NewSemaphore(n int) *Semaphore {
+	return &Semaphore{
+		slots: make(chan struct{}, n),
+	}
+}
+
+// Blocks until a slot is available or the context is done.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Frees the slot taken by Acquire.
+func (s *Semaphore) Release() {
+	<-s.slots
+}
@@ -1,6 +1,8 @@
 package networktest
 
 import (
+	"math"
+	"net/netip"
 	"netscan/internal/network"
 	"testing"
 
@@ -136,6 +138,116 @@ func TestParseCidr_ResultSequence(t *testing.T) {
 	})
 }
 
+func TestParseCidr_ShuffledSequence(t *testing.T) {
+	addrParser := network.NewAddrParser()
+
+	for _, cidr := range []string{"10.6.5.7/28", "192.168.0.0/23", "fd00:abcd:1234::/120"} {
+		t.Run("shuffle "+cidr, func(t *testing.T) {
+			err := addrParser.ParseCidrOrAddr(cidr)
+			require.NoError(t, err)
+			ordered := []string{}
+			for addr := range addrParser.Hosts() {
+				ordered = append(ordered, addr.String())
+			}
+			shuffled := []string{}
+			for addr := range addrParser.HostsShuffled(42) {
+				shuffled = append(shuffled, addr.String())
+			}
+			assert.ElementsMatch(t, ordered, shuffled)
+			assert.NotEqual(t, ordered, shuffled)
+
+			again := []string{}
+			for addr := range addrParser.HostsShuffled(42) {
+				again = append(again, addr.String())
+			}
+			assert.Equal(t, shuffled, again)
+		})
+	}
+}
+
+func collectHosts(p *network.AddrParser) []string {
+	hosts := []string{}
+	for addr := range p.Hosts() {
+		hosts = append(hosts, addr.String())
+	}
+	return hosts
+}
+
+func TestMultipleTargets(t *testing.T) {
+	t.Run("dash ranges", func(t *testing.T) {
+		addrParser := network.NewAddrParser()
+		require.NoError(t, addrParser.AddTarget("10.0.0.5-10.0.0.7"))
+		require.NoError(t, addrParser.AddTarget("10.0.1.5-7"))
+		require.NoError(t, addrParser.AddTarget("fd00::fe-fd00::101"))
+		assert.Equal(t, []string{
+			"10.0.0.5", "10.0.0.6", "10.0.0.7",
+			"10.0.1.5", "10.0.1.6", "10.0.1.7",
+			"fd00::fe", "fd00::ff", "fd00::100", "fd00::101",
+		}, collectHosts(addrParser))
+		assert.Equal(t, uint64(10), addrParser.GetHostsLength())
+		assert.False(t, addrParser.GetCIDR().IsValid())
+	})
+
+	t.Run("octet wildcards", func(t *testing.T) {
+		addrParser := network.NewAddrParser()
+		require.NoError(t, addrParser.AddTarget("192.168.1-3.*"))
+		assert.Equal(t, uint64(3*254), addrParser.GetHostsLength())
+		assert.Equal(t, "192.168.1.1", addrParser.GetHostsFirst().String())
+		assert.Equal(t, "192.168.3.254", addrParser.GetHostsLast().String())
+		assert.False(t, addrParser.Contains(netip.MustParseAddr("192.168.2.255")))
+		assert.True(t, addrParser.Contains(netip.MustParseAddr("192.168.2.1")))
+		assert.Equal(t, "192.168.0.0/22", addrParser.GetCIDR().String())
+	})
+
+	t.Run("overlapping targets", func(t *testing.T) {
+		addrParser := network.NewAddrParser()
+		require.NoError(t, addrParser.AddTarget("10.0.0.0/29"))
+		require.NoError(t, addrParser.AddTarget("10.0.0.4-10"))
+		require.NoError(t, addrParser.AddTarget("10.0.0.2"))
+		assert.Equal(t, []string{
+			"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5",
+			"10.0.0.6", "10.0.0.7", "10.0.0.8", "10.0.0.9", "10.0.0.10",
+		}, collectHosts(addrParser))
+		shuffled := []string{}
+		for addr := range addrParser.HostsShuffled(1) {
+			shuffled = append(shuffled, addr.String())
+		}
+		assert.ElementsMatch(t, collectHosts(addrParser), shuffled)
+	})
+
+	t.Run("exclusions", func(t *testing.T) {
+		addrParser := network.NewAddrParser()
+		require.NoError(t, addrParser.Exclude("10.0.0.3-5"))
+		require.NoError(t, addrParser.Exclude("10.0.0.8/30"))
+		require.NoError(t, addrParser.AddTarget("10.0.0.1-12"))
+		require.NoError(t, addrParser.Exclude("10.0.0.12"))
+		assert.Equal(t, []string{
+			"10.0.0.1", "10.0.0.2", "10.0.0.6", "10.0.0.7",
+		}, collectHosts(addrParser))
+	})
+
+	t.Run("total range limit", func(t *testing.T) {
+		addrParser := network.NewAddrParser()
+		require.NoError(t, addrParser.Exclude("10.0.0.0/24"))
+		require.NoError(t, addrParser.AddTarget("10.0.0.0/16"))
+		require.NoError(t, addrParser.AddTarget("10.1.0.0/24"))
+		assert.Equal(t, uint64(65533), addrParser.GetHostsLength())
+		require.Error(t, addrParser.AddTarget("10.2.0.1-4"))
+		assert.Equal(t, uint64(65533), addrParser.GetHostsLength())
+	})
+
+	t.Run("invalid targets", func(t *testing.T) {
+		addrParser := network.NewAddrParser()
+		require.Error(t, addrParser.AddTarget("10.0.0.50-10.0.0.5"))
+		require.Error(t, addrParser.AddTarget("10.0.0.5-fd00::1"))
+		require.Error(t, addrParser.AddTarget("10.0.0.5-256"))
+		require.Error(t, addrParser.AddTarget("10.0.*"))
+		require.Error(t, addrParser.AddTarget("10.*.*.*"))
+		require.Error(t, addrParser.AddTarget("10.255.255.250-11.0.0.5"))
+		assert.Equal(t, uint64(0), addrParser.GetHostsLength())
+	})
+}
+
 func TestParseErrors(t *testing.T) {
 	addrParser := network.NewAddrParser()
 
@@ -179,25 +291,66 @@ func TestNonPrivateAddressError(t *testing.T) {
 	})
 }
 
+func TestSpecialPurposeAndPublicAddresses(t *testing.T) {
+	addrParser := network.NewAddrParser()
+
+	for _, target := range []string{"100.64.1.0/24", "169.254.10.1", "fe80::1-fe80::ff"} {
+		t.Run("allowed "+target, func(t *testing.T) {
+			require.NoError(t, addrParser.ParseCidrOrAddr(target))
+			assert.False(t, addrParser.HasPublicHosts())
+		})
+	}
+
+	t.Run("public with --allow-public", func(t *testing.T) {
+		addrParser := network.NewAddrParser()
+		require.Error(t, addrParser.AddTarget("8.8.8.0/30"))
+		addrParser.SetAllowPublic(true)
+		require.NoError(t, addrParser.AddTarget("192.168.1.1"))
+		assert.False(t, addrParser.HasPublicHosts())
+		require.NoError(t, addrParser.AddTarget("8.8.8.0/30"))
+		assert.True(t, addrParser.HasPublicHosts())
+		assert.Equal(t, uint64(3), addrParser.GetHostsLength())
+	})
+}
+
+func TestHostNameTargets(t *testing.T) {
+	addrParser := network.NewAddrParser()
+	// loopback isn't private
+	require.Error(t, addrParser.AddTarget("localhost"))
+	require.Error(t, addrParser.AddTarget("no-such-host.invalid"))
+
+	addrParser.SetAllowPublic(true)
+	require.NoError(t, addrParser.AddTarget("localhost"))
+	loopback := netip.MustParseAddr("127.0.0.1")
+	assert.True(t, addrParser.Contains(loopback))
+	assert.Equal(t, "localhost", addrParser.GetTargetName(loopback))
+
+	require.NoError(t, addrParser.ParseCidrOrAddr("localhost/30"))
+	assert.Equal(t, "127.0.0.1", addrParser.GetHostsFirst().String())
+	assert.Equal(t, "127.0.0.2", addrParser.GetHostsLast().String())
+	assert.Equal(t, "localhost", addrParser.GetTargetName(loopback))
+	assert.Empty(t, addrParser.GetTargetName(netip.MustParseAddr("127.0.0.2")))
+}
+
 func TestRangeLengthLimits(t *testing.T) {
 	addrParser := network.NewAddrParser()
 
 	t.Run("range 192.168.1.1/32", func(t *testing.T) {
 		err := addrParser.ParseCidrOrAddr("192.168.1.1/32")
 		require.NoError(t, err)
-		assert.Equal(t, 1, addrParser.GetHostsLength())
+		assert.Equal(t, uint64(1), addrParser.GetHostsLength())
 	})
 
 	t.Run("range 192.168.1.5/31", func(t *testing.T) {
 		err := addrParser.ParseCidrOrAddr("192.168.1.5/31")
 		require.NoError(t, err)
-		assert.Equal(t, 2, addrParser.GetHostsLength())
+		assert.Equal(t, uint64(2), addrParser.GetHostsLength())
 	})
 
 	t.Run("range 192.168.10.0/24", func(t *testing.T) {
 		err := addrParser.ParseCidrOrAddr("192.168.10.0/24")
 		require.NoError(t, err)
-		assert.Equal(t, 254, addrParser.GetHostsLength())
+		assert.Equal(t, uint64(254), addrParser.GetHostsLength())
 	})
 
 	t.Run("range 10.0.0.0/8", func(t *testing.T) {
@@ -213,6 +366,54 @@ func TestRangeLengthLimits(t *testing.T) {
 	t.Run("range fd00:db8::/112", func(t *testing.T) {
 		err := addrParser.ParseCidrOrAddr("fd00:db8::/112")
 		require.NoError(t, err)
-		assert.Equal(t, 65536, addrParser.GetHostsLength())
+		assert.Equal(t, uint64(65536), addrParser.GetHostsLength())
+	})
+}
+
+func TestMaxHosts(t *testing.T) {
+	t.Run("default limit", func(t *testing.T) {
+		addrParser := network.NewAddrParser()
+		err := addrParser.AddTarget("10.0.0.0/8")
+		require.ErrorIs(t, err, network.ErrTooManyHosts)
+		require.ErrorIs(t, addrParser.AddTarget("10.*.*.*"), network.ErrTooManyHosts)
+		assert.Equal(t, uint64(0), addrParser.GetHostsLength())
+	})
+
+	t.Run("IPv4 /8", func(t *testing.T) {
+		addrParser := network.NewAddrParser()
+		addrParser.SetMaxHosts(1 << 24)
+		require.NoError(t, addrParser.AddTarget("10.0.0.0/8"))
+		assert.Equal(t, uint64(1<<24-2), addrParser.GetHostsLength())
+		assert.Equal(t, "10.255.255.254", addrParser.GetHostsLast().String())
+		require.ErrorIs(t, addrParser.AddTarget("172.16.0.1-3"), network.ErrTooManyHosts)
+		assert.Equal(t, uint64(1<<24-2), addrParser.GetHostsLength())
+
+		// iterated lazily, in any order
+		count := 0
+		for addr := range addrParser.HostsShuffled(7) {
+			require.True(t, addrParser.Contains(addr), addr)
+			count++
+			if count == 1000 {
+				break
+			}
+		}
+		assert.Equal(t, 1000, count)
+
+		require.NoError(t, addrParser.ParseCidrOrAddr("10.*.*.*"))
+		assert.Equal(t, uint64(1<<16*254), addrParser.GetHostsLength())
+		// too many ranges to list
+		require.Error(t, addrParser.ParseCidrOrAddr("*.*.*.1"))
+	})
+
+	t.Run("large IPv6 prefixes", func(t *testing.T) {
+		addrParser := network.NewAddrParser()
+		addrParser.SetMaxHosts(math.MaxUint64)
+		require.NoError(t, addrParser.AddTarget("fd00::/72"))
+		assert.Equal(t, uint64(1<<56), addrParser.GetHostsLength())
+		require.NoError(t, addrParser.AddTarget("fd01::/65"))
+		assert.Equal(t, uint64(1<<56+1<<63), addrParser.GetHostsLength())
+		// can't be counted in 64 bits
+		require.ErrorIs(t, addrParser.AddTarget("fd02::/64"), network.ErrTooManyHosts)
+		assert.Equal(t, uint64(1<<56+1<<63), addrParser.GetHostsLength())
 	})
 }
@@ -0,0 +1,31 @@
+package networktest
+
+import (
+	"net/netip"
+	"netscan/internal/network"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLocalSubnets(t *testing.T) {
+	subnets, err := network.GetLocalSubnets()
+	require.NoError(t, err)
+	for _, s := range subnets {
+		assert.NotEmpty(t, s.Interface)
+		assert.Equal(t, s.Prefix.Masked(), s.Prefix)
+		assert.False(t, s.Prefix.Addr().IsLoopback())
+		assert.False(t, s.Prefix.Addr().Is6() && s.Prefix.Addr().IsLinkLocalUnicast())
+	}
+}
+
+func TestGetInterfaceName(t *testing.T) {
+	subnets := []network.LocalSubnet{
+		{Interface: "eth0", Prefix: netip.MustParsePrefix("192.168.1.0/24")},
+		{Interface: "wlan0", Prefix: netip.MustParsePrefix("fd00:1::/64")},
+	}
+	assert.Equal(t, "eth0", network.GetInterfaceName(subnets, netip.MustParseAddr("192.168.1.77")))
+	assert.Equal(t, "wlan0", network.GetInterfaceName(subnets, netip.MustParseAddr("fd00:1::1%wlan0")))
+	assert.Empty(t, network.GetInterfaceName(subnets, netip.MustParseAddr("192.168.2.1")))
+}
@@ -0,0 +1,46 @@
+package networktest
+
+import (
+	"netscan/internal/network"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIPv6Strategies(t *testing.T) {
+	strategies, err := network.ParseIPv6Strategies("all")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, network.IPv6Strategies, strategies)
+
+	strategies, err = network.ParseIPv6Strategies("none")
+	require.NoError(t, err)
+	assert.Empty(t, strategies)
+
+	strategies, err = network.ParseIPv6Strategies("lowbyte, EUI64,lowbyte")
+	require.NoError(t, err)
+	assert.Equal(t, []string{network.IPv6EUI64, network.IPv6LowByte}, strategies)
+
+	_, err = network.ParseIPv6Strategies("lowbyte,bruteforce")
+	require.Error(t, err)
+}
+
+func TestLargeIPv6Prefix(t *testing.T) {
+	addrParser := network.NewAddrParser()
+	require.Error(t, addrParser.AddTarget("fd00:1::/64"))
+
+	addrParser.SetIPv6Generator(network.NewIPv6TargetsGenerator(&network.IPv6TargetsOptions{
+		Strategies: []string{network.IPv6LowByte},
+		Timeout:    10 * time.Millisecond,
+	}))
+	require.NoError(t, addrParser.AddTarget("fd00:1::/64"))
+	require.NoError(t, addrParser.AddTarget("fd00:1::f0-fd00:1::1ff"))
+	assert.Equal(t, uint64(0x1ff), addrParser.GetHostsLength())
+	assert.Equal(t, "fd00:1::1", addrParser.GetHostsFirst().String())
+	assert.Equal(t, "fd00:1::1ff", addrParser.GetHostsLast().String())
+
+	// small prefixes are scanned entirely
+	require.NoError(t, addrParser.ParseCidrOrAddr("fd00:2::/120"))
+	assert.Equal(t, uint64(256), addrParser.GetHostsLength())
+}
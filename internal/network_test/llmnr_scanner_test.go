@@ -0,0 +1,41 @@
+package networktest
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"netscan/internal/network/scanners"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLLMNRScanner(t *testing.T) {
+	// the responders listen on the fixed port
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:5355")
+	if err != nil {
+		t.Skip("LLMNR port is not available:", err)
+	}
+	conn.Close()
+	startStubDNSServer(t, "127.0.0.1:5355", map[string]string{
+		"1.0.0.127.in-addr.arpa": "DESKTOP-01",
+	})
+	scanner := scanners.NewLLMNRScanner(nil)
+	ctx := context.Background()
+
+	t.Run("responder", func(t *testing.T) {
+		target := &scanners.TargetInfo{Address: netip.MustParseAddr("127.0.0.1")}
+		require.NoError(t, scanner.ScanTimeout(ctx, target, time.Second))
+		assert.Equal(t, scanners.HostAlive, target.GetState())
+		assert.Equal(t, "DESKTOP-01", target.LLMNRName)
+	})
+
+	t.Run("no responder", func(t *testing.T) {
+		target := &scanners.TargetInfo{Address: netip.MustParseAddr("127.0.0.2")}
+		err := scanner.ScanTimeout(ctx, target, time.Second)
+		assert.ErrorIs(t, err, scanners.ErrDefinitelyNegative)
+		assert.Empty(t, target.LLMNRName)
+	})
+}
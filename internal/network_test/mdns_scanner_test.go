@@ -0,0 +1,204 @@
+package networktest
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"netscan/internal/network/dns"
+	"netscan/internal/network/scanners"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseName(t *testing.T) {
+	assert.Equal(t, "5.1.168.192.in-addr.arpa", dns.ReverseName(netip.MustParseAddr("192.168.1.5")))
+	assert.Equal(t,
+		"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+		dns.ReverseName(netip.MustParseAddr("2001:db8::1")))
+}
+
+func TestMDNSScanner(t *testing.T) {
+	// the responders listen on the fixed port
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:5353")
+	if err != nil {
+		t.Skip("mDNS port is not available:", err)
+	}
+	conn.Close()
+	startStubDNSServer(t, "127.0.0.1:5353", map[string]string{
+		"1.0.0.127.in-addr.arpa": "nas01.local.",
+	})
+	scanner := scanners.NewMDNSScanner(nil)
+	ctx := context.Background()
+
+	t.Run("responder", func(t *testing.T) {
+		target := &scanners.TargetInfo{Address: netip.MustParseAddr("127.0.0.1")}
+		require.NoError(t, scanner.ScanTimeout(ctx, target, time.Second))
+		assert.Equal(t, scanners.HostAlive, target.GetState())
+		assert.Equal(t, "nas01.local", target.MDNSName)
+	})
+
+	t.Run("no responder", func(t *testing.T) {
+		target := &scanners.TargetInfo{Address: netip.MustParseAddr("127.0.0.2")}
+		err := scanner.ScanTimeout(ctx, target, time.Second)
+		assert.ErrorIs(t, err, scanners.ErrDefinitelyNegative)
+		assert.Empty(t, target.MDNSName)
+	})
+
+	t.Run("browsing finishes", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		scanner.Discovered(ctx)
+		assert.NoError(t, ctx.Err())
+	})
+}
+
+func TestMDNSScanner_UnrelatedReplies(t *testing.T) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:5353")
+	if err != nil {
+		t.Skip("mDNS port is not available:", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			query, err := dns.Parse(buf[:n])
+			if err != nil {
+				continue
+			}
+			answer := dns.Resource{
+				Name:   query.Questions[0].Name,
+				Type:   dns.TypePTR,
+				Class:  dns.ClassINET,
+				Target: "intruder.local",
+			}
+			for _, m := range []*dns.Message{
+				// the query echoed
+				{ID: query.ID, Questions: query.Questions, Answers: []dns.Resource{answer}},
+				// wrong ID
+				{ID: query.ID + 1, Flags: dns.FlagResponse, Questions: query.Questions, Answers: []dns.Resource{answer}},
+				// another question
+				{ID: query.ID, Flags: dns.FlagResponse, Questions: []dns.Question{{
+					Name: "2.0.0.127.in-addr.arpa", Type: dns.TypePTR, Class: dns.ClassINET,
+				}}, Answers: []dns.Resource{answer}},
+			} {
+				b, err := m.Pack()
+				if err == nil {
+					conn.WriteTo(b, addr)
+				}
+			}
+		}
+	}()
+
+	scanner := scanners.NewMDNSScanner(nil)
+	target := &scanners.TargetInfo{Address: netip.MustParseAddr("127.0.0.1")}
+	require.NoError(t, scanner.ScanTimeout(context.Background(), target, 300*time.Millisecond))
+	assert.NotEqual(t, scanners.HostAlive, target.GetState())
+	assert.Empty(t, target.MDNSName)
+}
+
+func TestParseBrowseResponse(t *testing.T) {
+	src := netip.MustParseAddr("192.168.1.20")
+	instance := "Office Printer._ipp._tcp.local"
+	msg := &dns.Message{
+		Flags: dns.FlagResponse | dns.FlagAuthoritative,
+		Answers: []dns.Resource{
+			{Name: dns.ServicesName, Type: dns.TypePTR, Class: dns.ClassINET, Target: "_ipp._tcp.local"},
+			{Name: dns.ServicesName, Type: dns.TypePTR, Class: dns.ClassINET, Target: "_http._tcp.local"},
+			{Name: "_ipp._tcp.local", Type: dns.TypePTR, Class: dns.ClassINET, Target: instance},
+		},
+		Additionals: []dns.Resource{
+			{Name: instance, Type: dns.TypeSRV, Class: dns.ClassINET, Port: 631, Target: "printer.local"},
+			{Name: instance, Type: dns.TypeTXT, Class: dns.ClassINET, Text: []string{"txtvers=1", "", "ty=LaserJet"}},
+			{Name: "printer.local", Type: dns.TypeA, Class: dns.ClassINET, Addr: src},
+			{Name: "scanner.local", Type: dns.TypeAAAA, Class: dns.ClassINET, Addr: netip.MustParseAddr("fd00::5")},
+			// not an mDNS name
+			{Name: "printer.lab", Type: dns.TypeA, Class: dns.ClassINET, Addr: netip.MustParseAddr("192.168.1.21")},
+		},
+	}
+	// round trip through the wire format
+	b, err := msg.Pack()
+	require.NoError(t, err)
+	msg, err = dns.Parse(b)
+	require.NoError(t, err)
+
+	hosts, serviceTypes := scanners.ParseBrowseResponse(msg, src)
+	assert.Equal(t, []string{"_ipp._tcp.local", "_http._tcp.local"}, serviceTypes)
+	require.Len(t, hosts, 2)
+
+	printer := hosts[0]
+	assert.Equal(t, src, printer.Address)
+	assert.Equal(t, scanners.HostAlive, printer.GetState())
+	assert.Equal(t, "printer.local", printer.MDNSName)
+	assert.Equal(t, []scanners.MDNSService{
+		{Type: "_ipp._tcp", Name: "Office Printer", Port: 631, TXT: []string{"txtvers=1", "ty=LaserJet"}},
+		{Type: "_http._tcp"},
+	}, printer.Services)
+
+	other := hosts[1]
+	assert.Equal(t, "fd00::5", other.Address.String())
+	assert.Equal(t, scanners.HostAlive, other.GetState())
+	assert.Equal(t, "scanner.local", other.MDNSName)
+	assert.Empty(t, other.Services)
+}
+
+func TestParseBrowseResponse_SRVHostName(t *testing.T) {
+	src := netip.MustParseAddr("192.168.1.30")
+	msg := &dns.Message{
+		Flags: dns.FlagResponse,
+		Answers: []dns.Resource{
+			{Name: "_ssh._tcp.local", Type: dns.TypePTR, Class: dns.ClassINET, Target: "nas01._ssh._tcp.local"},
+			{Name: "nas01._ssh._tcp.local", Type: dns.TypeSRV, Class: dns.ClassINET, Port: 22, Target: "nas01.local"},
+		},
+	}
+	hosts, serviceTypes := scanners.ParseBrowseResponse(msg, src)
+	assert.Empty(t, serviceTypes)
+	require.Len(t, hosts, 1)
+	assert.Equal(t, "nas01.local", hosts[0].MDNSName)
+	assert.Equal(t, []scanners.MDNSService{{Type: "_ssh._tcp", Name: "nas01", Port: 22}}, hosts[0].Services)
+}
+
+func TestMergeDiscovered(t *testing.T) {
+	scanned := &scanners.TargetInfo{Address: netip.MustParseAddr("192.168.1.5"), MDNSName: "nas01.local"}
+	scanned.SetState(scanners.HostUnknown)
+	dead := &scanners.TargetInfo{Address: netip.MustParseAddr("192.168.1.6")}
+	results := []*scanners.TargetInfo{scanned, dead}
+
+	discovered := []*scanners.TargetInfo{
+		{
+			Address:  scanned.Address,
+			MDNSName: "other.local",
+			Services: []scanners.MDNSService{{Type: "_smb._tcp", Name: "NAS01", Port: 445}},
+		},
+		{
+			Address:  netip.MustParseAddr("192.168.1.7"),
+			MDNSName: "printer.local",
+			Services: []scanners.MDNSService{{Type: "_ipp._tcp"}},
+		},
+		// duplicate from another discoverer
+		{
+			Address:  netip.MustParseAddr("192.168.1.7"),
+			Services: []scanners.MDNSService{{Type: "_ipp._tcp", Name: "Printer", Port: 631}},
+		},
+	}
+	for _, d := range discovered {
+		d.SetState(scanners.HostAlive)
+	}
+	added := scanners.MergeDiscovered(results, discovered)
+
+	assert.Equal(t, scanners.HostAlive, scanned.GetState())
+	assert.Equal(t, "nas01.local", scanned.MDNSName)
+	assert.Equal(t, []scanners.MDNSService{{Type: "_smb._tcp", Name: "NAS01", Port: 445}}, scanned.Services)
+	assert.Equal(t, scanners.HostDead, dead.GetState())
+
+	require.Len(t, added, 1)
+	assert.Equal(t, "192.168.1.7", added[0].Address.String())
+	assert.Equal(t, "printer.local", added[0].MDNSName)
+	assert.Equal(t, []scanners.MDNSService{{Type: "_ipp._tcp", Name: "Printer", Port: 631}}, added[0].Services)
+}
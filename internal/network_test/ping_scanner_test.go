@@ -0,0 +1,63 @@
+//go:build linux
+
+package networktest
+
+import (
+	"context"
+	"net/netip"
+	"netscan/internal/network/scanners"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPingScanner_Loopback(t *testing.T) {
+	pingScanner := scanners.NewPingScanner(nil)
+	defer pingScanner.Close()
+
+	for _, addr := range []string{"127.0.0.1", "::1"} {
+		t.Run("ping "+addr, func(t *testing.T) {
+			target := &scanners.TargetInfo{
+				Address: netip.MustParseAddr(addr),
+			}
+			err := pingScanner.ScanTimeout(context.Background(), target, time.Second)
+			if err != nil && strings.Contains(err.Error(), "not permitted") {
+				t.Skip("ICMP sockets are not permitted for this user")
+			}
+			require.NoError(t, err)
+			assert.Equal(t, scanners.HostAlive, target.GetState())
+			assert.Greater(t, target.RTT, time.Duration(0))
+		})
+	}
+}
+
+func TestPingScanner_SharedSocket(t *testing.T) {
+	pingScanner := scanners.NewPingScanner(nil)
+	defer pingScanner.Close()
+
+	t.Run("sweep 127.0.0.1-127.0.0.64", func(t *testing.T) {
+		targets := make([]*scanners.TargetInfo, 0, 64)
+		for addr := netip.MustParseAddr("127.0.0.1"); len(targets) < 64; addr = addr.Next() {
+			targets = append(targets, &scanners.TargetInfo{Address: addr})
+		}
+		errs := make([]error, len(targets))
+		var wg sync.WaitGroup
+		for i, target := range targets {
+			wg.Go(func() {
+				errs[i] = pingScanner.ScanTimeout(context.Background(), target, time.Second)
+			})
+		}
+		wg.Wait()
+		for i, target := range targets {
+			if errs[i] != nil && strings.Contains(errs[i].Error(), "not permitted") {
+				t.Skip("ICMP sockets are not permitted for this user")
+			}
+			require.NoError(t, errs[i])
+			assert.Equal(t, scanners.HostAlive, target.GetState(), target.Address.String())
+		}
+	})
+}
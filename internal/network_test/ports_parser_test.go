@@ -0,0 +1,67 @@
+package networktest
+
+import (
+	"netscan/internal/network"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePorts(t *testing.T) {
+	t.Run("parse 22,80,443", func(t *testing.T) {
+		ports, err := network.ParsePorts("22,80,443")
+		require.NoError(t, err)
+		assert.Equal(t, []uint16{22, 80, 443}, ports)
+	})
+
+	t.Run("parse 22, 8000-8003", func(t *testing.T) {
+		ports, err := network.ParsePorts("22, 8000-8003")
+		require.NoError(t, err)
+		assert.Equal(t, []uint16{22, 8000, 8001, 8002, 8003}, ports)
+	})
+
+	t.Run("parse 80,79-81,80", func(t *testing.T) {
+		ports, err := network.ParsePorts("80,79-81,80")
+		require.NoError(t, err)
+		assert.Equal(t, []uint16{80, 79, 81}, ports)
+	})
+
+	t.Run("parse -", func(t *testing.T) {
+		ports, err := network.ParsePorts("-")
+		require.NoError(t, err)
+		assert.Len(t, ports, 65535)
+		assert.Equal(t, uint16(1), ports[0])
+		assert.Equal(t, uint16(65535), ports[65534])
+	})
+}
+
+func TestParsePortsPresets(t *testing.T) {
+	t.Run("parse top100", func(t *testing.T) {
+		ports, err := network.ParsePorts("top100")
+		require.NoError(t, err)
+		assert.Len(t, ports, 100)
+	})
+
+	t.Run("parse top1000", func(t *testing.T) {
+		ports, err := network.ParsePorts("top1000")
+		require.NoError(t, err)
+		assert.Len(t, ports, 1000)
+	})
+
+	t.Run("parse iot,windows", func(t *testing.T) {
+		ports, err := network.ParsePorts("iot,windows")
+		require.NoError(t, err)
+		assert.Contains(t, ports, uint16(9100))
+		assert.Contains(t, ports, uint16(5985))
+	})
+}
+
+func TestParsePortsErrors(t *testing.T) {
+	for _, s := range []string{"", "0", "65536", "80-22", "http", "22,", "1-2-3"} {
+		t.Run("parse "+s, func(t *testing.T) {
+			_, err := network.ParsePorts(s)
+			require.Error(t, err)
+		})
+	}
+}
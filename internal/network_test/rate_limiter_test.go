@@ -0,0 +1,44 @@
+package networktest
+
+import (
+	"context"
+	"netscan/internal/network/throttle"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("rate 100/s", func(t *testing.T) {
+		limiter := throttle.NewRateLimiter(100, 1)
+		start := time.Now()
+		for range 11 {
+			require.NoError(t, limiter.Wait(context.Background()))
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 95*time.Millisecond)
+	})
+
+	t.Run("burst 10", func(t *testing.T) {
+		limiter := throttle.NewRateLimiter(1, 10)
+		start := time.Now()
+		for range 10 {
+			require.NoError(t, limiter.Wait(context.Background()))
+		}
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		limiter := throttle.NewRateLimiter(1, 1)
+		require.NoError(t, limiter.Wait(context.Background()))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.Error(t, limiter.Wait(ctx))
+	})
+
+	t.Run("nil limiter", func(t *testing.T) {
+		var limiter *throttle.RateLimiter
+		require.NoError(t, limiter.Wait(context.Background()))
+	})
+}
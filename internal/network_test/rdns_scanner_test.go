@@ -0,0 +1,171 @@
+package networktest
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"netscan/internal/network/dns"
+	"netscan/internal/network/scanners"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Starts a DNS server on the loopback address answering PTR queries
+// from the names map. Returns the server address and the counter
+// of received queries.
+func startStubDNSServer(t *testing.T, address string, names map[string]string) (string, *atomic.Int32) {
+	conn, err := net.ListenPacket("udp4", address)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	queries := &atomic.Int32{}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			query, err := dns.Parse(buf[:n])
+			if err != nil || len(query.Questions) != 1 {
+				continue
+			}
+			queries.Add(1)
+			q := query.Questions[0]
+			resp := &dns.Message{
+				ID:        query.ID,
+				Flags:     dns.FlagResponse | dns.FlagAuthoritative | dns.RCodeNameError,
+				Questions: query.Questions,
+			}
+			if name, ok := names[q.Name]; ok && q.Type == dns.TypePTR {
+				resp.Flags = dns.FlagResponse | dns.FlagAuthoritative
+				resp.Answers = []dns.Resource{{
+					Name:   q.Name,
+					Type:   dns.TypePTR,
+					Class:  dns.ClassINET,
+					TTL:    60,
+					Target: name,
+				}}
+			}
+			b, err := resp.Pack()
+			if err == nil {
+				conn.WriteTo(b, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String(), queries
+}
+
+func TestRDNSScanner(t *testing.T) {
+	server, queries := startStubDNSServer(t, "127.0.0.1:0", map[string]string{
+		"5.1.168.192.in-addr.arpa": "nas01.lab.",
+	})
+	scanner := scanners.NewRDNSScanner(&scanners.RDNSScannerOptions{Server: server})
+	ctx := context.Background()
+
+	t.Run("known host", func(t *testing.T) {
+		target := &scanners.TargetInfo{Address: netip.MustParseAddr("192.168.1.5")}
+		target.SetState(scanners.HostAlive)
+		target.HostName = "NAS01"
+		require.NoError(t, scanner.ScanTimeout(ctx, target, time.Second))
+		assert.Equal(t, "nas01.lab", target.DNSName)
+		assert.Equal(t, "NAS01", target.HostName)
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		target := &scanners.TargetInfo{Address: netip.MustParseAddr("192.168.1.6")}
+		target.SetState(scanners.HostUnknown)
+		require.NoError(t, scanner.ScanTimeout(ctx, target, time.Second))
+		assert.Empty(t, target.DNSName)
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		before := queries.Load()
+		for _, addr := range []string{"192.168.1.5", "192.168.1.6"} {
+			target := &scanners.TargetInfo{Address: netip.MustParseAddr(addr)}
+			target.SetState(scanners.HostAlive)
+			require.NoError(t, scanner.ScanTimeout(ctx, target, time.Second))
+		}
+		assert.Equal(t, before, queries.Load())
+	})
+
+	t.Run("dead host is skipped", func(t *testing.T) {
+		before := queries.Load()
+		target := &scanners.TargetInfo{Address: netip.MustParseAddr("192.168.1.7")}
+		require.NoError(t, scanner.ScanTimeout(ctx, target, time.Second))
+		assert.Equal(t, before, queries.Load())
+	})
+}
+
+func TestRDNSScanner_ServerHostName(t *testing.T) {
+	names := map[string]string{
+		"5.1.168.192.in-addr.arpa": "nas01.lab.",
+	}
+	lookup := func(t *testing.T, server string) {
+		scanner := scanners.NewRDNSScanner(&scanners.RDNSScannerOptions{Server: server})
+		target := &scanners.TargetInfo{Address: netip.MustParseAddr("192.168.1.5")}
+		target.SetState(scanners.HostAlive)
+		require.NoError(t, scanner.ScanTimeout(context.Background(), target, time.Second))
+		assert.Equal(t, "nas01.lab", target.DNSName)
+	}
+
+	t.Run("with port", func(t *testing.T) {
+		server, _ := startStubDNSServer(t, "127.0.0.1:0", names)
+		_, port, err := net.SplitHostPort(server)
+		require.NoError(t, err)
+		lookup(t, net.JoinHostPort("localhost", port))
+	})
+
+	t.Run("default port", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp4", "127.0.0.1:53")
+		if err != nil {
+			t.Skip("DNS port is not available:", err)
+		}
+		conn.Close()
+		startStubDNSServer(t, "127.0.0.1:53", names)
+		lookup(t, "localhost")
+	})
+
+	t.Run("lookup error", func(t *testing.T) {
+		// nothing listens there anymore
+		conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+		require.NoError(t, err)
+		conn.Close()
+		server := conn.LocalAddr().String()
+		scanner := scanners.NewRDNSScanner(&scanners.RDNSScannerOptions{Server: server})
+		target := &scanners.TargetInfo{Address: netip.MustParseAddr("192.168.1.5")}
+		target.SetState(scanners.HostAlive)
+		assert.Error(t, scanner.ScanTimeout(context.Background(), target, time.Second))
+		assert.Empty(t, target.DNSName)
+	})
+}
+
+func TestScannersManager_Enrich(t *testing.T) {
+	server, queries := startStubDNSServer(t, "127.0.0.1:0", map[string]string{
+		"5.1.168.192.in-addr.arpa": "nas01.lab.",
+		"9.1.168.192.in-addr.arpa": "printer.lab.",
+	})
+	manager := scanners.NewScannersManager(&scanners.ScannersManagerOptions{
+		IncludeRDNS: true,
+		DNSServer:   server,
+	})
+	defer manager.Close()
+	// reverse DNS isn't a scanning step
+	assert.Equal(t, 0, manager.GetSteps())
+	assert.Equal(t, []string{"Reverse DNS"}, manager.GetNames())
+
+	alive := &scanners.TargetInfo{Address: netip.MustParseAddr("192.168.1.5")}
+	alive.SetState(scanners.HostAlive)
+	// found in the ARP cache after the scan
+	arpOnly := &scanners.TargetInfo{Address: netip.MustParseAddr("192.168.1.9")}
+	arpOnly.SetState(scanners.HostUnknown)
+	dead := &scanners.TargetInfo{Address: netip.MustParseAddr("192.168.1.7")}
+	require.NoError(t, manager.Enrich(context.Background(), []*scanners.TargetInfo{alive, arpOnly, dead}))
+	assert.Equal(t, "nas01.lab", alive.DNSName)
+	assert.Equal(t, "printer.lab", arpOnly.DNSName)
+	assert.Empty(t, dead.DNSName)
+	assert.Equal(t, int32(2), queries.Load())
+}
@@ -0,0 +1,41 @@
+package networktest
+
+import (
+	"net/netip"
+	"netscan/internal/network/scanners"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTTEstimator_Timeout(t *testing.T) {
+	host := netip.MustParseAddr("192.168.1.10")
+	neighbor := netip.MustParseAddr("192.168.1.20")
+	remote := netip.MustParseAddr("192.168.2.10")
+
+	t.Run("not enough samples", func(t *testing.T) {
+		estimator := scanners.NewRTTEstimator()
+		estimator.Update(host, 2*time.Millisecond)
+		assert.Equal(t, time.Second, estimator.Timeout(host, time.Second))
+	})
+
+	t.Run("shrink on fast subnet", func(t *testing.T) {
+		estimator := scanners.NewRTTEstimator()
+		for range 5 {
+			estimator.Update(host, 2*time.Millisecond)
+		}
+		assert.Equal(t, 50*time.Millisecond, estimator.Timeout(neighbor, time.Second))
+		assert.Equal(t, time.Second, estimator.Timeout(remote, time.Second))
+	})
+
+	t.Run("grow on slow subnet", func(t *testing.T) {
+		estimator := scanners.NewRTTEstimator()
+		for _, ms := range []int{400, 800, 1200, 600} {
+			estimator.Update(host, time.Duration(ms)*time.Millisecond)
+		}
+		timeout := estimator.Timeout(neighbor, 500*time.Millisecond)
+		assert.Greater(t, timeout, 500*time.Millisecond)
+		assert.LessOrEqual(t, timeout, 1500*time.Millisecond)
+	})
+}
@@ -0,0 +1,28 @@
+package networktest
+
+import (
+	"netscan/internal/network"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadTargets(t *testing.T) {
+	input := "# office subnets\r\n" +
+		"192.168.1.0/24\r\n" +
+		"\n" +
+		"10.0.0.5-50, 10.1.0.1 # printers\n" +
+		"  fd00::1\t192.168.3-4.*\n" +
+		"#10.2.0.0/24\n"
+	targets, err := network.ReadTargets(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"192.168.1.0/24",
+		"10.0.0.5-50",
+		"10.1.0.1",
+		"fd00::1",
+		"192.168.3-4.*",
+	}, targets)
+}
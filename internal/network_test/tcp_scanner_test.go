@@ -0,0 +1,101 @@
+package networktest
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"netscan/internal/network/scanners"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns a listening and a closed TCP port on the loopback interface.
+func loopbackPorts(t *testing.T) (uint16, uint16) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closed.Close()
+	return uint16(l.Addr().(*net.TCPAddr).Port), uint16(closed.Addr().(*net.TCPAddr).Port)
+}
+
+func TestTCPScanner_Loopback(t *testing.T) {
+	open, closed := loopbackPorts(t)
+
+	t.Run("full scan", func(t *testing.T) {
+		tcpScanner := scanners.NewTCPScanner(&scanners.TCPScannerOptions{
+			Ports: []uint16{closed, open},
+		})
+		target := &scanners.TargetInfo{
+			Address: netip.MustParseAddr("127.0.0.1"),
+		}
+		err := tcpScanner.ScanTimeout(context.Background(), target, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, scanners.HostAlive, target.GetState())
+		assert.Equal(t, []uint16{open}, target.OpenPorts)
+		assert.Equal(t, []uint16{closed}, target.ClosedPorts)
+	})
+
+	t.Run("concurrent full scan", func(t *testing.T) {
+		tcpScanner := scanners.NewTCPScanner(&scanners.TCPScannerOptions{
+			Ports:     []uint16{open, closed},
+			HostDials: 2,
+		})
+		target := &scanners.TargetInfo{
+			Address: netip.MustParseAddr("127.0.0.1"),
+		}
+		err := tcpScanner.ScanTimeout(context.Background(), target, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, []uint16{open}, target.OpenPorts)
+		assert.Equal(t, []uint16{closed}, target.ClosedPorts)
+	})
+
+	t.Run("discovery", func(t *testing.T) {
+		tcpScanner := scanners.NewTCPScanner(&scanners.TCPScannerOptions{
+			Ports:         []uint16{closed, open},
+			DiscoveryOnly: true,
+		})
+		target := &scanners.TargetInfo{
+			Address: netip.MustParseAddr("127.0.0.1"),
+		}
+		err := tcpScanner.ScanTimeout(context.Background(), target, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, scanners.HostAlive, target.GetState())
+		assert.Len(t, append(target.OpenPorts, target.ClosedPorts...), 1)
+	})
+}
+
+func TestTCPScanner_ConcurrentDials(t *testing.T) {
+	// the documentation range is not routed, the attempts time out
+	target := netip.MustParseAddr("192.0.2.77")
+	const timeout = 300 * time.Millisecond
+	start := time.Now()
+	err := scanners.NewTCPScanner(&scanners.TCPScannerOptions{Ports: []uint16{1}}).
+		ScanTimeout(context.Background(), &scanners.TargetInfo{Address: target}, timeout)
+	require.NoError(t, err)
+	if time.Since(start) < timeout {
+		t.Skip("the connection attempts are answered on this network")
+	}
+
+	ports := []uint16{1, 2, 3, 4, 5, 6, 7, 8}
+	tcpScanner := scanners.NewTCPScanner(&scanners.TCPScannerOptions{
+		Ports:     ports,
+		HostDials: len(ports),
+	})
+	start = time.Now()
+	err = tcpScanner.ScanTimeout(context.Background(), &scanners.TargetInfo{Address: target}, timeout)
+	require.NoError(t, err)
+	// sequential dialing would take len(ports) * timeout
+	assert.Less(t, time.Since(start), 2*timeout)
+}
+
+func TestHostThreads(t *testing.T) {
+	assert.Equal(t, scanners.DefaultTCPHostDials, scanners.HostThreads(0, 100, 255))
+	assert.Equal(t, 5, scanners.HostThreads(16, 5, 255))
+	assert.Equal(t, 4, scanners.HostThreads(16, 100, 4))
+	assert.Equal(t, 1, scanners.HostThreads(16, 0, 255))
+}
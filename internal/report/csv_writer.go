@@ -0,0 +1,130 @@
+package report
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"netscan/internal/network/scanners"
+	"strconv"
+	"strings"
+)
+
+// Columns available for the delimited text formats.
+const (
+	ColumnIP        = "ip"
+	ColumnState     = "state"
+	ColumnMac       = "mac"
+	ColumnHostName  = "hostname"
+	ColumnDNSName   = "dnsname"
+	ColumnMDNSName  = "mdnsname"
+	ColumnLLMNRName = "llmnrname"
+	ColumnServices  = "services"
+	ColumnWorkgroup = "workgroup"
+	ColumnInterface = "interface"
+	ColumnTarget    = "target"
+	ColumnPorts     = "ports"
+	ColumnRTT       = "rtt"
+	ColumnComments  = "comments"
+)
+
+// Default set and order of the columns.
+var DefaultColumns = []string{
+	ColumnIP,
+	ColumnTarget,
+	ColumnState,
+	ColumnMac,
+	ColumnHostName,
+	ColumnDNSName,
+	ColumnMDNSName,
+	ColumnLLMNRName,
+	ColumnWorkgroup,
+	ColumnInterface,
+	ColumnPorts,
+	ColumnServices,
+	ColumnRTT,
+	ColumnComments,
+}
+
+// Returns the column value of the host scan results.
+var columnValues = map[string]func(t *scanners.TargetInfo) string{
+	ColumnIP:        func(t *scanners.TargetInfo) string { return t.Address.String() },
+	ColumnState:     func(t *scanners.TargetInfo) string { return t.GetState().String() },
+	ColumnMac:       func(t *scanners.TargetInfo) string { return t.Mac },
+	ColumnHostName:  func(t *scanners.TargetInfo) string { return t.HostName },
+	ColumnDNSName:   func(t *scanners.TargetInfo) string { return t.DNSName },
+	ColumnMDNSName:  func(t *scanners.TargetInfo) string { return t.MDNSName },
+	ColumnLLMNRName: func(t *scanners.TargetInfo) string { return t.LLMNRName },
+	ColumnWorkgroup: func(t *scanners.TargetInfo) string { return t.Workgroup },
+	ColumnInterface: func(t *scanners.TargetInfo) string { return t.Interface },
+	ColumnTarget:    func(t *scanners.TargetInfo) string { return t.TargetName },
+	ColumnPorts: func(t *scanners.TargetInfo) string {
+		ports := make([]string, 0, len(t.OpenPorts))
+		for _, p := range t.OpenPorts {
+			ports = append(ports, strconv.Itoa(int(p)))
+		}
+		return strings.Join(ports, " ")
+	},
+	ColumnRTT: func(t *scanners.TargetInfo) string {
+		if t.RTT == 0 {
+			return ""
+		}
+		// milliseconds
+		return formatRTT(t.RTT)
+	},
+	ColumnServices: func(t *scanners.TargetInfo) string {
+		services := make([]string, 0, len(t.Services))
+		for _, s := range t.Services {
+			services = append(services, formatService(s))
+		}
+		return strings.Join(services, "; ")
+	},
+	ColumnComments: func(t *scanners.TargetInfo) string { return strings.Join(t.Comments, "; ") },
+}
+
+// Indicates that the requested column doesn't exist.
+var ErrUnknownColumn = errors.New("unknown column")
+
+type CSVWriter struct {
+	w       *csv.Writer
+	columns []string
+}
+
+// This writer outputs the results as a delimited text table
+// with a header row; comma is used for CSV and tab for TSV.
+// Empty columns slice means DefaultColumns.
+func NewCSVWriter(w io.Writer, delimiter rune, columns []string) (*CSVWriter, error) {
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	for _, c := range columns {
+		if _, ok := columnValues[c]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, c)
+		}
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = delimiter
+	return &CSVWriter{
+		w:       cw,
+		columns: columns,
+	}, nil
+}
+
+func (c *CSVWriter) Write(info *ScanInfo, results []*scanners.TargetInfo) error {
+	err := c.w.Write(c.columns)
+	if err != nil {
+		return err
+	}
+	row := make([]string, len(c.columns))
+	for _, r := range results {
+		for i, col := range c.columns {
+			row[i] = columnValues[col](r)
+		}
+		err = c.w.Write(row)
+		if err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
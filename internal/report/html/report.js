@@ -0,0 +1,47 @@
+(function () {
+  "use strict";
+
+  var table = document.getElementById("hosts");
+  var body = table.tBodies[0];
+  var headers = table.tHead.rows[0].cells;
+
+  function cellValue(row, idx, type) {
+    var cell = row.cells[idx];
+    var text = cell.getAttribute("data-sort") || cell.textContent.trim();
+    if (type === "number") {
+      var n = parseFloat(text);
+      return isNaN(n) ? Infinity : n;
+    }
+    return text.toLowerCase();
+  }
+
+  function sortBy(idx) {
+    var th = headers[idx];
+    var type = th.getAttribute("data-type");
+    var asc = !th.classList.contains("asc");
+    for (var i = 0; i < headers.length; i++) {
+      headers[i].classList.remove("asc", "desc");
+    }
+    th.classList.add(asc ? "asc" : "desc");
+    var rows = Array.prototype.slice.call(body.rows);
+    rows.sort(function (a, b) {
+      var va = cellValue(a, idx, type);
+      var vb = cellValue(b, idx, type);
+      if (va < vb) return asc ? -1 : 1;
+      if (va > vb) return asc ? 1 : -1;
+      return 0;
+    });
+    rows.forEach(function (r) { body.appendChild(r); });
+  }
+
+  for (var i = 0; i < headers.length; i++) {
+    headers[i].addEventListener("click", sortBy.bind(null, i));
+  }
+
+  document.getElementById("filter").addEventListener("input", function (e) {
+    var q = e.target.value.trim().toLowerCase();
+    Array.prototype.forEach.call(body.rows, function (r) {
+      r.style.display = r.textContent.toLowerCase().indexOf(q) >= 0 ? "" : "none";
+    });
+  });
+})();
@@ -0,0 +1,142 @@
+package report
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"netscan/internal/network/scanners"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed html
+var htmlFiles embed.FS
+
+var htmlTemplate = template.Must(template.ParseFS(htmlFiles, "html/report.html.tmpl"))
+
+// Data passed to the HTML template.
+type htmlReport struct {
+	Version     string
+	Target      string
+	Scanners    string
+	StartTime   string
+	EndTime     string
+	Duration    string
+	Interrupted bool
+	HostsCount  uint64
+	Hosts       []htmlHost
+	Style       template.CSS
+	Script      template.JS
+}
+
+type htmlHost struct {
+	Address    string
+	SortKey    string
+	State      string
+	StateClass string
+	Mac        string
+	Vendor     string
+	HostName   string
+	Workgroup  string
+	Interface  string
+	TargetName string
+	DNSName    string
+	MDNSName   string
+	LLMNRName  string
+	Services   []htmlService
+	OpenPorts  string
+	RTT        string
+	Comments   []string
+}
+
+type htmlService struct {
+	Description string
+	TXT         string
+}
+
+type HTMLWriter struct {
+	w io.Writer
+}
+
+// This writer outputs the results as a self-contained HTML page
+// with a sortable and filterable hosts table
+func NewHTMLWriter(w io.Writer) *HTMLWriter {
+	return &HTMLWriter{w: w}
+}
+
+func (h *HTMLWriter) Write(info *ScanInfo, results []*scanners.TargetInfo) error {
+	style, err := htmlFiles.ReadFile("html/report.css")
+	if err != nil {
+		return err
+	}
+	script, err := htmlFiles.ReadFile("html/report.js")
+	if err != nil {
+		return err
+	}
+	report := htmlReport{
+		Version:     info.Version,
+		Target:      info.Target,
+		Scanners:    strings.Join(info.Scanners, ", "),
+		StartTime:   info.StartTime.Format(time.DateTime),
+		EndTime:     info.EndTime.Format(time.DateTime),
+		Duration:    info.EndTime.Sub(info.StartTime).Round(time.Millisecond).String(),
+		Interrupted: info.Interrupted,
+		HostsCount:  info.HostsCount,
+		Hosts:       make([]htmlHost, 0, len(results)),
+		Style:       template.CSS(style),
+		Script:      template.JS(script),
+	}
+	for _, r := range results {
+		report.Hosts = append(report.Hosts, newHTMLHost(r))
+	}
+	return htmlTemplate.Execute(h.w, report)
+}
+
+func newHTMLHost(t *scanners.TargetInfo) htmlHost {
+	host := htmlHost{
+		Address:    t.Address.String(),
+		State:      t.GetState().String(),
+		Mac:        t.Mac,
+		Vendor:     macVendor(t.Mac),
+		HostName:   t.HostName,
+		Workgroup:  t.Workgroup,
+		Interface:  t.Interface,
+		TargetName: t.TargetName,
+		DNSName:    t.DNSName,
+		MDNSName:   t.MDNSName,
+		LLMNRName:  t.LLMNRName,
+		Comments:   t.Comments,
+	}
+	for _, s := range t.Services {
+		host.Services = append(host.Services, htmlService{
+			Description: formatService(s),
+			TXT:         strings.Join(s.TXT, ", "),
+		})
+	}
+	// sortable representation of the address
+	if t.Address.Is4() {
+		b := t.Address.As4()
+		host.SortKey = fmt.Sprintf("%03d.%03d.%03d.%03d", b[0], b[1], b[2], b[3])
+	} else {
+		host.SortKey = t.Address.StringExpanded()
+	}
+	switch t.GetState() {
+	case scanners.HostAlive:
+		host.StateClass = "badge-ok"
+	case scanners.HostUnknown:
+		host.StateClass = "badge-warn"
+	default:
+		host.StateClass = "badge-dead"
+	}
+	ports := make([]string, 0, len(t.OpenPorts))
+	for _, p := range t.OpenPorts {
+		ports = append(ports, strconv.Itoa(int(p)))
+	}
+	host.OpenPorts = strings.Join(ports, ", ")
+	if t.RTT > 0 {
+		host.RTT = formatRTT(t.RTT)
+	}
+	return host
+}
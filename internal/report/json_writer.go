@@ -0,0 +1,37 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"netscan/internal/network/scanners"
+)
+
+// Top-level JSON document.
+type jsonDocument struct {
+	Version int          `json:"version"`
+	Scan    scanRecord   `json:"scan"`
+	Hosts   []hostRecord `json:"hosts"`
+}
+
+type JSONWriter struct {
+	w io.Writer
+}
+
+// This writer outputs the results as a single JSON document
+func NewJSONWriter(w io.Writer) *JSONWriter {
+	return &JSONWriter{w: w}
+}
+
+func (j *JSONWriter) Write(info *ScanInfo, results []*scanners.TargetInfo) error {
+	doc := jsonDocument{
+		Version: FormatVersion,
+		Scan:    newScanRecord(info),
+		Hosts:   make([]hostRecord, 0, len(results)),
+	}
+	for _, r := range results {
+		doc.Hosts = append(doc.Hosts, newHostRecord(r))
+	}
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,117 @@
+package report
+
+import (
+	"netscan/internal/network/scanners"
+	"strconv"
+	"time"
+)
+
+// Version of the machine-readable report formats.
+// Increment on incompatible changes of the documents layout.
+const FormatVersion = 1
+
+// Scan metadata.
+type ScanInfo struct {
+	Version     string // netscan version
+	CommandLine string
+	Target      string // target expressions as given, comma-separated
+	HostsCount  uint64 // number of addresses in the scan range
+	Scanners    []string
+	StartTime   time.Time
+	EndTime     time.Time
+	Interrupted bool
+}
+
+// Serializable representation of the scan metadata.
+type scanRecord struct {
+	Target      string    `json:"target"`
+	Scanners    []string  `json:"scanners"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	Interrupted bool      `json:"interrupted"`
+}
+
+func newScanRecord(info *ScanInfo) scanRecord {
+	return scanRecord{
+		Target:      info.Target,
+		Scanners:    info.Scanners,
+		StartTime:   info.StartTime,
+		EndTime:     info.EndTime,
+		Interrupted: info.Interrupted,
+	}
+}
+
+// Serializable representation of the host scan results.
+type hostRecord struct {
+	Address    string          `json:"address"`
+	State      string          `json:"state"`
+	Mac        string          `json:"mac,omitempty"`
+	HostName   string          `json:"hostname,omitempty"`
+	Workgroup  string          `json:"workgroup,omitempty"`
+	Interface  string          `json:"interface,omitempty"`
+	TargetName string          `json:"target_name,omitempty"`
+	DNSName    string          `json:"dns_name,omitempty"`
+	MDNSName   string          `json:"mdns_name,omitempty"`
+	LLMNRName  string          `json:"llmnr_name,omitempty"`
+	Services   []serviceRecord `json:"services,omitempty"`
+	OpenPorts  []uint16        `json:"open_ports,omitempty"`
+	RTT        float64         `json:"rtt_ms,omitempty"`
+	Comments   []string        `json:"comments,omitempty"`
+}
+
+func newHostRecord(t *scanners.TargetInfo) hostRecord {
+	return hostRecord{
+		Address:    t.Address.String(),
+		State:      t.GetState().String(),
+		Mac:        t.Mac,
+		HostName:   t.HostName,
+		Workgroup:  t.Workgroup,
+		Interface:  t.Interface,
+		TargetName: t.TargetName,
+		DNSName:    t.DNSName,
+		MDNSName:   t.MDNSName,
+		LLMNRName:  t.LLMNRName,
+		Services:   newServiceRecords(t.Services),
+		OpenPorts:  t.OpenPorts,
+		RTT:        float64(t.RTT.Microseconds()) / 1000,
+		Comments:   t.Comments,
+	}
+}
+
+// Serializable representation of the DNS-SD service.
+type serviceRecord struct {
+	Type string   `json:"type"`
+	Name string   `json:"name,omitempty"`
+	Port uint16   `json:"port,omitempty"`
+	TXT  []string `json:"txt,omitempty"`
+}
+
+func newServiceRecords(services []scanners.MDNSService) []serviceRecord {
+	if len(services) == 0 {
+		return nil
+	}
+	records := make([]serviceRecord, 0, len(services))
+	for _, s := range services {
+		records = append(records, serviceRecord(s))
+	}
+	return records
+}
+
+// Returns the round-trip time in milliseconds with the microseconds
+// precision, so that the replies under a millisecond aren't shown as 0.
+func formatRTT(rtt time.Duration) string {
+	return strconv.FormatFloat(float64(rtt.Microseconds())/1000, 'f', -1, 64)
+}
+
+// Returns the short description of the DNS-SD service,
+// like "_ipp._tcp:631 Office Printer".
+func formatService(s scanners.MDNSService) string {
+	str := s.Type
+	if s.Port != 0 {
+		str += ":" + strconv.Itoa(int(s.Port))
+	}
+	if len(s.Name) > 0 {
+		str += " " + s.Name
+	}
+	return str
+}
@@ -0,0 +1,92 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"net/netip"
+	"netscan/internal/network/scanners"
+	"reflect"
+	"sync"
+)
+
+// Types of the NDJSON records.
+const (
+	ndjsonTypeHost    = "host"
+	ndjsonTypeArpHost = "arp_host"
+	// found by mDNS services browsing
+	ndjsonTypeMDNSHost = "mdns_host"
+	// streamed host enriched after the scan
+	ndjsonTypeHostUpdate = "host_update"
+	ndjsonTypeSummary    = "summary"
+)
+
+type ndjsonHostRecord struct {
+	Type string `json:"type"`
+	hostRecord
+}
+
+type ndjsonSummaryRecord struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+	scanRecord
+	HostsCount int `json:"hosts_count"`
+}
+
+type NDJSONWriter struct {
+	mu       sync.Mutex
+	enc      *json.Encoder
+	streamed map[netip.Addr]hostRecord
+}
+
+// This writer outputs newline-delimited JSON records:
+// one per host as soon as it's scanned, then the hosts
+// discovered after the scan (ARP cache, mDNS) and the complete
+// records of the streamed hosts enriched after the scan
+// (e.g. MACs), then the summary.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{
+		enc:      json.NewEncoder(w),
+		streamed: make(map[netip.Addr]hostRecord),
+	}
+}
+
+func (n *NDJSONWriter) WriteHost(target *scanners.TargetInfo) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	record := newHostRecord(target)
+	n.streamed[target.Address] = record
+	return n.enc.Encode(ndjsonHostRecord{
+		Type:       ndjsonTypeHost,
+		hostRecord: record,
+	})
+}
+
+func (n *NDJSONWriter) Write(info *ScanInfo, results []*scanners.TargetInfo) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, r := range results {
+		record := newHostRecord(r)
+		recordType := ndjsonTypeArpHost
+		if streamed, ok := n.streamed[r.Address]; ok {
+			if reflect.DeepEqual(streamed, record) {
+				continue
+			}
+			recordType = ndjsonTypeHostUpdate
+		} else if len(r.Services) > 0 || len(r.MDNSName) > 0 {
+			recordType = ndjsonTypeMDNSHost
+		}
+		err := n.enc.Encode(ndjsonHostRecord{
+			Type:       recordType,
+			hostRecord: record,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return n.enc.Encode(ndjsonSummaryRecord{
+		Type:       ndjsonTypeSummary,
+		Version:    FormatVersion,
+		scanRecord: newScanRecord(info),
+		HostsCount: len(results),
+	})
+}
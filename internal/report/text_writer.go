@@ -0,0 +1,84 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"netscan/internal/network/scanners"
+	"netscan/internal/ui"
+	"strings"
+)
+
+type TextWriter struct {
+	w io.Writer
+}
+
+// This writer outputs the results as a human-readable colored text
+func NewTextWriter(w io.Writer) *TextWriter {
+	return &TextWriter{w: w}
+}
+
+func (t *TextWriter) Write(info *ScanInfo, results []*scanners.TargetInfo) error {
+	fmt.Fprintln(t.w)
+	for _, r := range results {
+		state := r.GetState()
+		address := r.Address.String()
+		if len(r.TargetName) > 0 {
+			address = fmt.Sprintf("%s (%s)", r.TargetName, r.Address)
+		}
+		if state != scanners.HostAlive && state != scanners.HostUnknown {
+			fmt.Fprintf(t.w, "Scanned %s with state %s\n", address, state)
+		} else {
+			if state == scanners.HostAlive {
+				fmt.Fprintln(t.w, ui.SprintfSuccess("%s is %s", address, state))
+			}
+			if state == scanners.HostUnknown {
+				fmt.Fprintln(t.w, ui.SprintfWarn("%s is %s", address, state))
+			}
+			if len(r.Mac) > 0 {
+				fmt.Fprintf(t.w, "\t%s\n", r.Mac)
+			}
+			if len(r.HostName) > 0 {
+				fmt.Fprintf(t.w, "\t%s\n", r.HostName)
+			}
+			if len(r.DNSName) > 0 {
+				fmt.Fprintf(t.w, "\t%s\n", r.DNSName)
+			}
+			if len(r.LLMNRName) > 0 {
+				fmt.Fprintf(t.w, "\t%s\n", r.LLMNRName)
+			}
+			if len(r.MDNSName) > 0 {
+				fmt.Fprintf(t.w, "\t%s\n", r.MDNSName)
+			}
+			if len(r.Workgroup) > 0 {
+				fmt.Fprintf(t.w, "\t%s\n", r.Workgroup)
+			}
+			if len(r.Interface) > 0 {
+				fmt.Fprintf(t.w, "\tvia %s\n", r.Interface)
+			}
+		}
+		if len(r.OpenPorts) > 0 {
+			ports := make([]string, 0, len(r.OpenPorts))
+			for _, p := range r.OpenPorts {
+				ports = append(ports, fmt.Sprintf("%d/TCP", p))
+			}
+			fmt.Fprintf(t.w, "\t\t%s open\n", strings.Join(ports, ", "))
+		}
+		for _, s := range r.Services {
+			fmt.Fprintf(t.w, "\t\t%s\n", formatService(s))
+			if len(s.TXT) > 0 {
+				fmt.Fprintf(t.w, "\t\t\t%s\n", strings.Join(s.TXT, ", "))
+			}
+		}
+		if r.RTT > 0 {
+			fmt.Fprintf(t.w, "\t\tICMP Echo RTT %s ms\n", formatRTT(r.RTT))
+		}
+		for _, c := range r.Comments {
+			fmt.Fprintf(t.w, "\t\t%s\n", c)
+		}
+		_, err := fmt.Fprintln(t.w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
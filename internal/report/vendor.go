@@ -0,0 +1,53 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+)
+
+const vendorLocal = "Locally administered"
+
+// Vendors by the first three bytes of the MAC address, read once
+// from the embedded table.
+var vendors = sync.OnceValue(func() map[[3]byte]string {
+	table := make(map[[3]byte]string)
+	data, err := htmlFiles.ReadFile("html/oui.txt")
+	if err != nil {
+		return table
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prefix, name, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		mac, err := net.ParseMAC(prefix + ":00:00:00")
+		if err != nil {
+			continue
+		}
+		table[[3]byte(mac)] = strings.TrimSpace(name)
+	}
+	return table
+})
+
+// Returns the vendor of the network adapter by its MAC address,
+// or an empty string if the vendor is unknown.
+// Randomized (private) addresses used by the phones and laptops,
+// as well as the virtual adapters, are locally administered.
+func macVendor(mac string) string {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) < 3 {
+		return ""
+	}
+	if hw[0]&0x02 != 0 {
+		return vendorLocal
+	}
+	return vendors()[[3]byte(hw)]
+}
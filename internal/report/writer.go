@@ -0,0 +1,63 @@
+package report
+
+import (
+	"errors"
+	"io"
+	"netscan/internal/network/scanners"
+)
+
+// Supported output formats.
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+	FormatTSV    = "tsv"
+	FormatXML    = "xml"
+)
+
+// Unified interface for all scan results writers
+type Writer interface {
+	// Writes the sorted scan results along with the scan metadata.
+	Write(info *ScanInfo, results []*scanners.TargetInfo) error
+}
+
+// Interface for writers able to output each host
+// right after its scanning is finished
+type StreamWriter interface {
+	Writer
+	// Writes a single host scan results, safe for concurrent use.
+	WriteHost(target *scanners.TargetInfo) error
+}
+
+// Configure the output format and its options
+type WriterOptions struct {
+	Format  string
+	Columns []string // only for CSV and TSV
+}
+
+// Returns a results writer configured by options outputting to w.
+func NewWriter(options *WriterOptions, w io.Writer) (Writer, error) {
+	switch options.Format {
+	case FormatText, "":
+		return NewTextWriter(w), nil
+	case FormatJSON:
+		return NewJSONWriter(w), nil
+	case FormatNDJSON:
+		return NewNDJSONWriter(w), nil
+	case FormatCSV:
+		return NewCSVWriter(w, ',', options.Columns)
+	case FormatTSV:
+		return NewCSVWriter(w, '\t', options.Columns)
+	case FormatXML:
+		return NewXMLWriter(w), nil
+	default:
+		return nil, errors.New("unknown output format")
+	}
+}
+
+// Returns true if the format is intended for machine processing
+// and must not be mixed with informational messages.
+func IsMachineReadable(format string) bool {
+	return format != FormatText && format != ""
+}
@@ -0,0 +1,250 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"netscan/internal/network/scanners"
+	"slices"
+	"strings"
+	"time"
+)
+
+/*
+	The output mimics the nmap XML format, see
+	https://nmap.org/book/nmap-dtd.html
+	Only the elements required by the common consumers
+	(nmaprun/host/status/address/hostnames/ports/times/runstats) are produced.
+*/
+
+const nmapXMLOutputVersion = "1.05"
+
+type nmapRun struct {
+	XMLName          xml.Name     `xml:"nmaprun"`
+	Scanner          string       `xml:"scanner,attr"`
+	Args             string       `xml:"args,attr,omitempty"`
+	Start            int64        `xml:"start,attr"`
+	StartStr         string       `xml:"startstr,attr"`
+	Version          string       `xml:"version,attr"`
+	XMLOutputVersion string       `xml:"xmloutputversion,attr"`
+	Hosts            []nmapHost   `xml:"host"`
+	RunStats         nmapRunStats `xml:"runstats"`
+}
+
+type nmapHost struct {
+	Status    nmapStatus     `xml:"status"`
+	Addresses []nmapAddress  `xml:"address"`
+	Hostnames []nmapHostname `xml:"hostnames>hostname"`
+	Ports     *nmapPorts     `xml:"ports,omitempty"`
+	Times     *nmapTimes     `xml:"times,omitempty"`
+}
+
+type nmapStatus struct {
+	State     string `xml:"state,attr"`
+	Reason    string `xml:"reason,attr"`
+	ReasonTTL int    `xml:"reason_ttl,attr"`
+}
+
+type nmapAddress struct {
+	Addr     string `xml:"addr,attr"`
+	AddrType string `xml:"addrtype,attr"`
+}
+
+type nmapHostname struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type nmapPorts struct {
+	Ports []nmapPort `xml:"port"`
+}
+
+type nmapPort struct {
+	Protocol string     `xml:"protocol,attr"`
+	PortID   uint16     `xml:"portid,attr"`
+	State    nmapStatus `xml:"state"`
+}
+
+type nmapTimes struct {
+	SRTT   int64 `xml:"srtt,attr"`
+	RTTVar int64 `xml:"rttvar,attr"`
+	To     int64 `xml:"to,attr"`
+}
+
+type nmapRunStats struct {
+	Finished nmapFinished `xml:"finished"`
+	Hosts    nmapHosts    `xml:"hosts"`
+}
+
+type nmapFinished struct {
+	Time     int64  `xml:"time,attr"`
+	TimeStr  string `xml:"timestr,attr"`
+	Elapsed  string `xml:"elapsed,attr"`
+	Summary  string `xml:"summary,attr"`
+	Exit     string `xml:"exit,attr"`
+	ErrorMsg string `xml:"errormsg,attr,omitempty"`
+}
+
+type nmapHosts struct {
+	Up    int    `xml:"up,attr"`
+	Down  uint64 `xml:"down,attr"`
+	Total uint64 `xml:"total,attr"`
+}
+
+type XMLWriter struct {
+	w io.Writer
+}
+
+// This writer outputs the results as an nmap-compatible XML report
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	return &XMLWriter{w: w}
+}
+
+func (x *XMLWriter) Write(info *ScanInfo, results []*scanners.TargetInfo) error {
+	run := nmapRun{
+		Scanner:          "netscan",
+		Args:             info.CommandLine,
+		Start:            info.StartTime.Unix(),
+		StartStr:         info.StartTime.Format(time.ANSIC),
+		Version:          info.Version,
+		XMLOutputVersion: nmapXMLOutputVersion,
+		Hosts:            make([]nmapHost, 0, len(results)),
+	}
+	for _, r := range results {
+		run.Hosts = append(run.Hosts, newNmapHost(r))
+	}
+
+	// every host in the results has responded somehow
+	up := len(results)
+	total := max(info.HostsCount, uint64(up))
+	elapsed := info.EndTime.Sub(info.StartTime).Seconds()
+	run.RunStats = nmapRunStats{
+		Finished: nmapFinished{
+			Time:    info.EndTime.Unix(),
+			TimeStr: info.EndTime.Format(time.ANSIC),
+			Elapsed: fmt.Sprintf("%.2f", elapsed),
+			Summary: fmt.Sprintf("netscan done at %s; %d IP addresses (%d hosts up) scanned in %.2f seconds",
+				info.EndTime.Format(time.ANSIC), total, up, elapsed),
+			Exit: "success",
+		},
+		Hosts: nmapHosts{
+			Up:    up,
+			Down:  total - uint64(up),
+			Total: total,
+		},
+	}
+	if info.Interrupted {
+		run.RunStats.Finished.Exit = "error"
+		run.RunStats.Finished.ErrorMsg = "Interrupted"
+	}
+
+	_, err := io.WriteString(x.w, xml.Header+"<!DOCTYPE nmaprun>\n")
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(x.w)
+	enc.Indent("", "  ")
+	err = enc.Encode(run)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(x.w, "\n")
+	return err
+}
+
+func newNmapHost(t *scanners.TargetInfo) nmapHost {
+	addrType := "ipv4"
+	if t.Address.Is6() && !t.Address.Is4In6() {
+		addrType = "ipv6"
+	}
+	host := nmapHost{
+		Status: nmapStatus{
+			State:  "up",
+			Reason: nmapHostReason(t),
+		},
+		Addresses: []nmapAddress{
+			{Addr: t.Address.String(), AddrType: addrType},
+		},
+	}
+	if len(t.Mac) > 0 {
+		host.Addresses = append(host.Addresses, nmapAddress{
+			Addr:     strings.ToUpper(t.Mac),
+			AddrType: "mac",
+		})
+	}
+	if len(t.TargetName) > 0 {
+		host.Hostnames = append(host.Hostnames, nmapHostname{
+			Name: t.TargetName,
+			Type: "user",
+		})
+	}
+	if len(t.HostName) > 0 {
+		host.Hostnames = append(host.Hostnames, nmapHostname{
+			Name: t.HostName,
+			Type: "user",
+		})
+	}
+	if len(t.DNSName) > 0 {
+		host.Hostnames = append(host.Hostnames, nmapHostname{
+			Name: t.DNSName,
+			Type: "PTR",
+		})
+	}
+	if len(t.LLMNRName) > 0 {
+		host.Hostnames = append(host.Hostnames, nmapHostname{
+			Name: t.LLMNRName,
+			Type: "PTR",
+		})
+	}
+	if len(t.MDNSName) > 0 {
+		host.Hostnames = append(host.Hostnames, nmapHostname{
+			Name: t.MDNSName,
+			Type: "PTR",
+		})
+	}
+	if len(t.OpenPorts) > 0 || len(t.ClosedPorts) > 0 {
+		ports := &nmapPorts{}
+		for _, p := range t.OpenPorts {
+			ports.Ports = append(ports.Ports, nmapPort{
+				Protocol: "tcp",
+				PortID:   p,
+				State:    nmapStatus{State: "open", Reason: "syn-ack"},
+			})
+		}
+		for _, p := range t.ClosedPorts {
+			ports.Ports = append(ports.Ports, nmapPort{
+				Protocol: "tcp",
+				PortID:   p,
+				State:    nmapStatus{State: "closed", Reason: "conn-refused"},
+			})
+		}
+		slices.SortFunc(ports.Ports, func(a, b nmapPort) int {
+			return int(a.PortID) - int(b.PortID)
+		})
+		host.Ports = ports
+	}
+	if t.RTT > 0 {
+		rtt := t.RTT.Microseconds()
+		host.Times = &nmapTimes{
+			SRTT: rtt,
+			To:   max(rtt*4, 100000),
+		}
+	}
+	return host
+}
+
+// Returns the nmap-style reason of the host being up.
+func nmapHostReason(t *scanners.TargetInfo) string {
+	switch {
+	case t.RTT > 0:
+		return "echo-reply"
+	case len(t.OpenPorts) > 0:
+		return "syn-ack"
+	case len(t.ClosedPorts) > 0:
+		return "conn-refused"
+	case len(t.Mac) > 0:
+		return "arp-response"
+	default:
+		return "user-set"
+	}
+}
@@ -0,0 +1,69 @@
+package reporttest
+
+import (
+	"netscan/internal/network/scanners"
+	"netscan/internal/report"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVWriter(t *testing.T) {
+	host := testHost("192.168.1.5")
+	host.Comments = []string{"a, b", "c\td"}
+	results := []*scanners.TargetInfo{host}
+
+	tests := []struct {
+		name      string
+		delimiter rune
+		columns   []string
+		expected  string
+	}{
+		{
+			name:      "default columns",
+			delimiter: ',',
+			expected: "ip,target,state,mac,hostname,dnsname,mdnsname,llmnrname,workgroup,interface,ports,services,rtt,comments\n" +
+				"192.168.1.5,,Online,,NAS01,,,,,,22 445,,,\"a, b; c\td\"\n",
+		},
+		{
+			name:      "selected columns",
+			delimiter: ',',
+			columns:   []string{"hostname", "ip"},
+			expected:  "hostname,ip\nNAS01,192.168.1.5\n",
+		},
+		{
+			name:      "quoted comma",
+			delimiter: ',',
+			columns:   []string{"ip", "comments"},
+			expected:  "ip,comments\n192.168.1.5,\"a, b; c\td\"\n",
+		},
+		{
+			name:      "quoted tab",
+			delimiter: '\t',
+			columns:   []string{"ip", "comments"},
+			expected:  "ip\tcomments\n192.168.1.5\t\"a, b; c\td\"\n",
+		},
+		{
+			name:      "tsv",
+			delimiter: '\t',
+			columns:   []string{"ip", "ports"},
+			expected:  "ip\tports\n192.168.1.5\t22 445\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			w, err := report.NewCSVWriter(&out, tt.delimiter, tt.columns)
+			require.NoError(t, err)
+			require.NoError(t, w.Write(testScanInfo(), results))
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+
+	t.Run("unknown column", func(t *testing.T) {
+		_, err := report.NewCSVWriter(&strings.Builder{}, ',', []string{"ip", "vendor"})
+		assert.ErrorIs(t, err, report.ErrUnknownColumn)
+	})
+}
@@ -0,0 +1,45 @@
+package reporttest
+
+import (
+	"netscan/internal/network/scanners"
+	"netscan/internal/report"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLWriter(t *testing.T) {
+	host := testHost("192.168.1.5")
+	host.HostName = `<script>alert("x")</script>`
+	host.Comments = []string{"a & b"}
+	info := testScanInfo()
+	info.Target = "<b>lab</b>"
+	var out strings.Builder
+	require.NoError(t, report.NewHTMLWriter(&out).Write(info, []*scanners.TargetInfo{host}))
+
+	html := out.String()
+	assert.NotContains(t, html, `<script>alert`)
+	assert.Contains(t, html, `&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;`)
+	assert.NotContains(t, html, "<b>lab</b>")
+	assert.Contains(t, html, "&lt;b&gt;lab&lt;/b&gt;")
+	assert.Contains(t, html, "a &amp; b")
+	assert.Contains(t, html, "192.168.1.5")
+}
+
+func TestHTMLWriter_Vendor(t *testing.T) {
+	host := testHost("192.168.1.5")
+	host.Mac = "B8-27-EB-12-34-56"
+	unknown := testHost("192.168.1.6")
+	unknown.Mac = "00:00:01:12:34:56"
+	results := []*scanners.TargetInfo{host, unknown, testArpHost("192.168.1.9")}
+	var out strings.Builder
+	require.NoError(t, report.NewHTMLWriter(&out).Write(testScanInfo(), results))
+
+	html := out.String()
+	assert.Contains(t, html, "<th>Vendor</th>")
+	assert.Contains(t, html, "<td>Raspberry Pi</td>")
+	assert.Contains(t, html, "<td>Locally administered</td>")
+	assert.Equal(t, 1, strings.Count(html, "<td></td>\n        <td>NAS01</td>"))
+}
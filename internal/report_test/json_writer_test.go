@@ -0,0 +1,54 @@
+package reporttest
+
+import (
+	"encoding/json"
+	"netscan/internal/network/scanners"
+	"netscan/internal/report"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONWriter(t *testing.T) {
+	var out strings.Builder
+	results := []*scanners.TargetInfo{testHost("192.168.1.5"), testArpHost("192.168.1.9")}
+	require.NoError(t, report.NewJSONWriter(&out).Write(testScanInfo(), results))
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &doc))
+	assert.ElementsMatch(t, []string{"version", "scan", "hosts"}, keys(doc))
+	assert.EqualValues(t, report.FormatVersion, doc["version"])
+
+	scan := doc["scan"].(map[string]any)
+	assert.Equal(t, "192.168.1.0/24", scan["target"])
+	assert.Equal(t, []any{"TCP Scan", "ARP Table"}, scan["scanners"])
+	assert.Equal(t, "2026-01-02T03:04:05Z", scan["start_time"])
+	assert.Equal(t, false, scan["interrupted"])
+
+	hosts := doc["hosts"].([]any)
+	require.Len(t, hosts, 2)
+	assert.Equal(t, map[string]any{
+		"address":    "192.168.1.5",
+		"state":      "Online",
+		"hostname":   "NAS01",
+		"open_ports": []any{22.0, 445.0},
+	}, hosts[0])
+	// the empty fields are omitted
+	assert.ElementsMatch(t, []string{"address", "state", "mac"}, keys(hosts[1].(map[string]any)))
+
+	t.Run("no hosts", func(t *testing.T) {
+		var out strings.Builder
+		require.NoError(t, report.NewJSONWriter(&out).Write(testScanInfo(), nil))
+		assert.Contains(t, out.String(), `"hosts": []`)
+	})
+}
+
+func keys(m map[string]any) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
@@ -0,0 +1,57 @@
+package reporttest
+
+import (
+	"encoding/json"
+	"netscan/internal/network/scanners"
+	"netscan/internal/report"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNDJSONWriter(t *testing.T) {
+	var out strings.Builder
+	w := report.NewNDJSONWriter(&out)
+
+	// streamed during the scan
+	unchanged := testHost("192.168.1.5")
+	enriched := testHost("192.168.1.6")
+	require.NoError(t, w.WriteHost(unchanged))
+	require.NoError(t, w.WriteHost(enriched))
+
+	// merged after the scan
+	enriched.Mac = "11:22:33:44:55:66"
+	mdnsOnly := &scanners.TargetInfo{
+		Address:  testHost("192.168.1.7").Address,
+		MDNSName: "printer.local",
+		Services: []scanners.MDNSService{{Type: "_ipp._tcp", Name: "Printer", Port: 631}},
+	}
+	mdnsOnly.SetState(scanners.HostAlive)
+	results := []*scanners.TargetInfo{unchanged, enriched, mdnsOnly, testArpHost("192.168.1.9")}
+	require.NoError(t, w.Write(testScanInfo(), results))
+
+	type record struct {
+		Type       string `json:"type"`
+		Address    string `json:"address"`
+		Mac        string `json:"mac"`
+		Version    int    `json:"version"`
+		HostsCount int    `json:"hosts_count"`
+	}
+	var records []record
+	for line := range strings.Lines(out.String()) {
+		var r record
+		require.NoError(t, json.Unmarshal([]byte(line), &r), line)
+		records = append(records, r)
+	}
+	require.Len(t, records, 6)
+	assert.Equal(t, []record{
+		{Type: "host", Address: "192.168.1.5"},
+		{Type: "host", Address: "192.168.1.6"},
+		{Type: "host_update", Address: "192.168.1.6", Mac: "11:22:33:44:55:66"},
+		{Type: "mdns_host", Address: "192.168.1.7"},
+		{Type: "arp_host", Address: "192.168.1.9", Mac: "aa:bb:cc:dd:ee:ff"},
+		{Type: "summary", Version: report.FormatVersion, HostsCount: 4},
+	}, records)
+}
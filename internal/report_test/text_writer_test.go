@@ -0,0 +1,25 @@
+package reporttest
+
+import (
+	"netscan/internal/network/scanners"
+	"netscan/internal/report"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextWriter_RTT(t *testing.T) {
+	fast := testHost("192.168.1.5")
+	fast.RTT = 250 * time.Microsecond
+	slow := testHost("192.168.1.6")
+	slow.RTT = 12*time.Millisecond + 340*time.Microsecond
+	var out strings.Builder
+	require.NoError(t, report.NewTextWriter(&out).Write(testScanInfo(), []*scanners.TargetInfo{fast, slow}))
+
+	assert.Contains(t, out.String(), "ICMP Echo RTT 0.25 ms")
+	assert.Contains(t, out.String(), "ICMP Echo RTT 12.34 ms")
+	assert.NotContains(t, out.String(), "RTT 0 ms")
+}
@@ -0,0 +1,71 @@
+package reporttest
+
+import (
+	"net/netip"
+	"netscan/internal/network/scanners"
+	"netscan/internal/report"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testScanInfo() *report.ScanInfo {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &report.ScanInfo{
+		Version:     "v.test",
+		CommandLine: "netscan 192.168.1.0/24",
+		Target:      "192.168.1.0/24",
+		HostsCount:  254,
+		Scanners:    []string{"TCP Scan", "ARP Table"},
+		StartTime:   start,
+		EndTime:     start.Add(3 * time.Second),
+	}
+}
+
+// Returns a host found by the scanners.
+func testHost(addr string) *scanners.TargetInfo {
+	h := &scanners.TargetInfo{
+		Address:   netip.MustParseAddr(addr),
+		HostName:  "NAS01",
+		OpenPorts: []uint16{22, 445},
+	}
+	h.SetState(scanners.HostAlive)
+	return h
+}
+
+// Returns a host found only in the ARP cache.
+func testArpHost(addr string) *scanners.TargetInfo {
+	h := &scanners.TargetInfo{
+		Address: netip.MustParseAddr(addr),
+		Mac:     "aa:bb:cc:dd:ee:ff",
+	}
+	h.SetState(scanners.HostUnknown)
+	return h
+}
+
+func TestNewWriter(t *testing.T) {
+	for _, format := range []string{
+		report.FormatText,
+		report.FormatJSON,
+		report.FormatNDJSON,
+		report.FormatCSV,
+		report.FormatTSV,
+		report.FormatXML,
+	} {
+		t.Run(format, func(t *testing.T) {
+			var out strings.Builder
+			w, err := report.NewWriter(&report.WriterOptions{Format: format}, &out)
+			require.NoError(t, err)
+			require.NoError(t, w.Write(testScanInfo(), []*scanners.TargetInfo{testHost("192.168.1.5")}))
+			assert.Contains(t, out.String(), "192.168.1.5")
+		})
+	}
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := report.NewWriter(&report.WriterOptions{Format: "yaml"}, &strings.Builder{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,72 @@
+package reporttest
+
+import (
+	"encoding/xml"
+	"netscan/internal/network/scanners"
+	"netscan/internal/report"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXMLWriter(t *testing.T) {
+	host := testHost("192.168.1.5")
+	host.Mac = "aa:bb:cc:dd:ee:ff"
+	host.ClosedPorts = []uint16{80}
+	var out strings.Builder
+	require.NoError(t, report.NewXMLWriter(&out).Write(testScanInfo(), []*scanners.TargetInfo{host}))
+
+	// the element and attribute names read by the nmap XML consumers
+	var run struct {
+		XMLName xml.Name `xml:"nmaprun"`
+		Scanner string   `xml:"scanner,attr"`
+		Hosts   []struct {
+			Status struct {
+				State string `xml:"state,attr"`
+			} `xml:"status"`
+			Addresses []struct {
+				Addr     string `xml:"addr,attr"`
+				AddrType string `xml:"addrtype,attr"`
+			} `xml:"address"`
+			Hostnames []struct {
+				Name string `xml:"name,attr"`
+			} `xml:"hostnames>hostname"`
+			Ports []struct {
+				Protocol string `xml:"protocol,attr"`
+				PortID   uint16 `xml:"portid,attr"`
+				State    struct {
+					State string `xml:"state,attr"`
+				} `xml:"state"`
+			} `xml:"ports>port"`
+		} `xml:"host"`
+		RunStats struct {
+			Hosts struct {
+				Up    int `xml:"up,attr"`
+				Total int `xml:"total,attr"`
+			} `xml:"hosts"`
+		} `xml:"runstats"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(out.String()), &run))
+	assert.Equal(t, "netscan", run.Scanner)
+	require.Len(t, run.Hosts, 1)
+	h := run.Hosts[0]
+	assert.Equal(t, "up", h.Status.State)
+	require.Len(t, h.Addresses, 2)
+	assert.Equal(t, "192.168.1.5", h.Addresses[0].Addr)
+	assert.Equal(t, "ipv4", h.Addresses[0].AddrType)
+	assert.Equal(t, "AA:BB:CC:DD:EE:FF", h.Addresses[1].Addr)
+	assert.Equal(t, "mac", h.Addresses[1].AddrType)
+	require.Len(t, h.Hostnames, 1)
+	assert.Equal(t, "NAS01", h.Hostnames[0].Name)
+
+	states := map[uint16]string{}
+	for _, p := range h.Ports {
+		assert.Equal(t, "tcp", p.Protocol)
+		states[p.PortID] = p.State.State
+	}
+	assert.Equal(t, map[uint16]string{22: "open", 445: "open", 80: "closed"}, states)
+	assert.Equal(t, 1, run.RunStats.Hosts.Up)
+	assert.Equal(t, 254, run.RunStats.Hosts.Total)
+}
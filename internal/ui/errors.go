@@ -10,3 +10,7 @@ var ErrArgParsing = errors.New("error parsing arguments")
 // Indicates that the help message has been shown,
 // the program should exit without error.
 var ErrHelpShown = errors.New("help shown")
+
+// The confirmation can't be asked, since the standard input
+// isn't a terminal or is used for other data.
+var ErrNotInteractive = errors.New("standard input is not a terminal")
@@ -1,9 +1,23 @@
 package ui
 
 import (
+	"bufio"
+	"os"
+	"strings"
+
 	"github.com/pterm/pterm"
 )
 
+// Redirects all the messages to the standard error stream,
+// keeping the standard output clean for the results.
+func RedirectMessagesToStderr() {
+	pterm.SetDefaultOutput(os.Stderr)
+	pterm.Info.Writer = os.Stderr
+	pterm.Error.Writer = os.Stderr
+	pterm.Warning.Writer = os.Stderr
+	pterm.Success.Writer = os.Stderr
+}
+
 func PrintflnLabeledError(format string, a ...any) {
 	pterm.Error.Printfln(format, a...)
 }
@@ -12,6 +26,29 @@ func PrintflnLabeledInfo(format string, a ...any) {
 	pterm.Info.Printfln(format, a...)
 }
 
+func PrintflnLabeledWarning(format string, a ...any) {
+	pterm.Warning.Printfln(format, a...)
+}
+
+// Asks the user a yes/no question, anything but "y" or "yes" is a no.
+// Returns ErrNotInteractive if the standard input isn't a terminal.
+func Confirm(format string, a ...any) (bool, error) {
+	if !IsInteractive() {
+		return false, ErrNotInteractive
+	}
+	pterm.Warning.Printf(format+" [y/N] ", a...)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// Returns true if the standard input is a terminal,
+// so that the user may answer the questions.
+func IsInteractive() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
 func PrintflnInfo(format string, a ...any) {
 	pterm.ThemeDefault.InfoMessageStyle.Printfln(format, a...)
 }
@@ -23,3 +60,11 @@ func PrintflnSuccess(format string, a ...any) {
 func PrintflnWarn(format string, a ...any) {
 	pterm.ThemeDefault.WarningMessageStyle.Printfln(format, a...)
 }
+
+func SprintfSuccess(format string, a ...any) string {
+	return pterm.ThemeDefault.SuccessMessageStyle.Sprintf(format, a...)
+}
+
+func SprintfWarn(format string, a ...any) string {
+	return pterm.ThemeDefault.WarningMessageStyle.Sprintf(format, a...)
+}
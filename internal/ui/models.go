@@ -1,19 +1,56 @@
 package ui
 
+import "time"
+
 // Options structure holds parsed command line options
 type Options struct {
-	CIDR           string
-	IsVerbose      bool
-	UseTCPScan     bool
-	UseNbstat      bool
-	UsePing        bool
-	UseArpCache    bool
-	UseFingerprint bool
-	UseBannerGrab  bool
-	Threads        uint16
+	Targets         []string
+	Auto            bool
+	TargetsFile     string
+	Exclude         []string
+	ExcludeFile     string
+	AllowPublic     bool
+	MaxHosts        uint64
+	IPv6Strategies  string
+	AssumeYes       bool
+	IsVerbose       bool
+	UseTCPScan      bool
+	UseNbstat       bool
+	UsePing         bool
+	UseArpCache     bool
+	UseMDNS         bool
+	UseLLMNR        bool
+	UseRDNS         bool
+	DNSServer       string
+	RDNSThreads     uint16
+	RDNSTimeout     time.Duration
+	MDNSTimeout     time.Duration
+	LLMNRTimeout    time.Duration
+	UseFingerprint  bool
+	UseBannerGrab   bool
+	Threads         uint16
+	Randomize       bool
+	Seed            uint64
+	IsSeedSet       bool
+	PortThreads     uint16
+	Rate            float64
+	Burst           uint16
+	Timeout         time.Duration
+	TCPTimeout      time.Duration
+	NbstatTimeout   time.Duration
+	PingTimeout     time.Duration
+	MaxScanTime     time.Duration
+	AdaptiveTimeout bool
+	Retries         uint8
+	Ports           string
+	DiscoveryOnly   bool
+	OutputFormat    string
+	OutputColumns   []string
+	HTMLReportPath  string
 }
 
 // Returns true is any of the available scanners is selected for usage.
+// The enrichment ones, like reverse DNS, don't count.
 func (o *Options) IsAnyScanSelected() bool {
-	return o.UseTCPScan || o.UsePing || o.UseNbstat || o.UseArpCache
+	return o.UseTCPScan || o.UsePing || o.UseNbstat || o.UseArpCache || o.UseMDNS || o.UseLLMNR
 }
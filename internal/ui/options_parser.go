@@ -2,27 +2,67 @@ package ui
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 )
 
-const strUsage = "<IP address or CIDR range> [OPTIONS]"
+const strUsage = "[targets...] [OPTIONS]"
 
 const strDescription = `
 netscan is a program that allows to discover devices on the local network.
 
-CIDR range format example:
-  192.168.0.0/24 for addresses from 192.168.0.1 to 192.168.0.254`
+Targets are IP addresses, CIDR ranges, dash ranges or octet wildcards, e.g.:
+  192.168.0.0/24 for addresses from 192.168.0.1 to 192.168.0.254
+  10.0.0.5-10.0.0.50 or 10.0.0.5-50 for addresses from 10.0.0.5 to 10.0.0.50
+  192.168.1-3.* for addresses from 192.168.1.1 to 192.168.3.254, skipping .0 and .255
+  nas01.lab or nas01.lab/24 for the host or its subnet, resolved via DNS
+Without targets, the subnets of the local network interfaces are scanned.`
 
 // Options definition for jessevdk/go-flags package.
 type cliOptions struct {
-	Tcp     bool   `short:"c" long:"tcp" description:"Enable TCP connect probing"`
-	Nbstat  bool   `short:"n" long:"nbstat" description:"Enable NetBIOS NBSTAT probing (IPv4 only)"`
-	Ping    bool   `short:"p" long:"ping" description:"Enable ping (ICMP echo) scanning"`
-	Arp     bool   `short:"a" long:"arp" description:"Enable ARP passive discovery"`
-	Threads uint16 `short:"t" long:"threads" description:"Override number of concurrent threads to use (up to 65,535)"`
-	Verbose bool   `short:"v" long:"verbose" description:"Verbose output"`
+	Tcp             bool          `short:"c" long:"tcp" description:"Enable TCP connect probing"`
+	Nbstat          bool          `short:"n" long:"nbstat" description:"Enable NetBIOS NBSTAT probing (IPv4 only)"`
+	Ping            bool          `short:"p" long:"ping" description:"Enable ping (ICMP echo) scanning"`
+	Arp             bool          `short:"a" long:"arp" description:"Enable ARP passive discovery"`
+	MDNS            bool          `short:"m" long:"mdns" description:"Enable mDNS name queries and DNS-SD services browsing"`
+	LLMNR           bool          `short:"l" long:"llmnr" description:"Enable LLMNR name queries, useful against Windows machines without NetBIOS"`
+	RDNS            bool          `long:"rdns" description:"Look up DNS names (PTR records) of the found hosts"`
+	DNSServer       string        `long:"dns-server" value-name:"HOST[:PORT]" description:"Name server for --rdns lookups instead of the system resolver"`
+	RDNSThreads     uint16        `long:"rdns-threads" description:"Max concurrent --rdns lookups (default: 8)"`
+	Discover        bool          `short:"d" long:"discover" description:"Fast host discovery: stop TCP probing at the first response instead of checking all the ports"`
+	Ports           string        `long:"ports" description:"TCP ports to probe: list and ranges (22,80,8000-8100), presets (top100, top1000, iot, windows) or - for all"`
+	Auto            bool          `long:"auto" description:"Scan the subnets of the local network interfaces, the default if no targets are given"`
+	TargetsFile     string        `short:"f" long:"targets-file" value-name:"FILE" description:"Read targets from a file (- for the standard input), one or more per line, # starts a comment"`
+	Exclude         []string      `short:"x" long:"exclude" description:"Targets to skip, comma-separated, may be repeated"`
+	ExcludeFile     string        `long:"exclude-file" value-name:"FILE" description:"Read targets to skip from a file, one or more per line, # starts a comment"`
+	IPv6Strategies  string        `long:"ipv6-strategies" default:"all" description:"How to pick hosts in IPv6 prefixes too large to scan every address: neighbors, lowbyte, eui64, mdns, all or none"`
+	AllowPublic     bool          `long:"allow-public" description:"Allow scanning public (non-private) addresses, asks for confirmation"`
+	MaxHosts        uint64        `long:"max-hosts" default:"65536" description:"Maximum number of addresses to scan, asks for confirmation above the default"`
+	Yes             bool          `short:"y" long:"yes" description:"Don't ask for confirmations"`
+	Threads         uint16        `short:"t" long:"threads" description:"Override number of concurrent threads to use (up to 65,535)"`
+	Randomize       bool          `short:"r" long:"randomize" description:"Scan the addresses in a pseudo-random order"`
+	Seed            uint64        `long:"seed" description:"Seed of the --randomize order for reproducible scans, implies --randomize (default: random)"`
+	PortThreads     uint16        `long:"port-threads" description:"Max concurrent TCP connection attempts per host, taken from the --threads budget (default: 16)"`
+	Rate            float64       `long:"rate" description:"Limit the probes (TCP connection attempts, UDP and ICMP packets) rate per second"`
+	Burst           uint16        `long:"burst" description:"Number of probes allowed to exceed --rate at once (default: 1)"`
+	Timeout         time.Duration `long:"timeout" description:"Timeout of each scanner per host, e.g. 500ms or 2s (default: 1s)"`
+	TCPTimeout      time.Duration `long:"tcp-timeout" description:"TCP connection timeout, overrides --timeout"`
+	NbstatTimeout   time.Duration `long:"nbstat-timeout" description:"NBSTAT response timeout, overrides --timeout"`
+	PingTimeout     time.Duration `long:"ping-timeout" description:"ICMP echo reply timeout, overrides --timeout"`
+	RDNSTimeout     time.Duration `long:"rdns-timeout" description:"Reverse DNS lookup timeout, overrides --timeout"`
+	MDNSTimeout     time.Duration `long:"mdns-timeout" description:"mDNS response timeout, overrides --timeout"`
+	LLMNRTimeout    time.Duration `long:"llmnr-timeout" description:"LLMNR response timeout, overrides --timeout"`
+	MaxScanTime     time.Duration `long:"max-scan-time" description:"Stop the whole scan after this time, e.g. 5m"`
+	AdaptiveTimeout bool          `long:"adaptive-timeout" description:"Adjust the timeouts to the round-trip times observed on the scanned subnets"`
+	Retries         uint8         `long:"retries" description:"Retry each scanner up to N times if a host doesn't respond, with exponential backoff"`
+	Verbose         bool          `short:"v" long:"verbose" description:"Verbose output"`
+	Output          string        `short:"o" long:"output" choice:"text" choice:"json" choice:"ndjson" choice:"csv" choice:"tsv" choice:"xml" default:"text" description:"Results output format"`
+	HTML            string        `long:"html" value-name:"FILE" description:"Also save the results as a self-contained HTML report"`
+	Columns         string        `long:"columns" description:"Comma-separated list of CSV/TSV columns: ip,target,state,mac,hostname,dnsname,mdnsname,llmnrname,workgroup,interface,ports,services,rtt,comments"`
 }
 
 type OptionsParser struct {
@@ -66,17 +106,78 @@ func (p *OptionsParser) ParseArgs() (*Options, error) {
 		}
 		return nil, err
 	}
-	if len(args) < 1 {
-		p.ShowHelpMessage()
-		return nil, ErrHelpShown
+	for _, t := range []time.Duration{
+		p.opts.Timeout,
+		p.opts.TCPTimeout,
+		p.opts.NbstatTimeout,
+		p.opts.PingTimeout,
+		p.opts.RDNSTimeout,
+		p.opts.MDNSTimeout,
+		p.opts.LLMNRTimeout,
+		p.opts.MaxScanTime,
+	} {
+		if t < 0 {
+			return nil, fmt.Errorf("%w: negative duration %v", ErrArgParsing, t)
+		}
+	}
+	if p.opts.Rate < 0 {
+		return nil, fmt.Errorf("%w: negative rate %v", ErrArgParsing, p.opts.Rate)
+	}
+	var exclude []string
+	for _, e := range p.opts.Exclude {
+		for t := range strings.SplitSeq(e, ",") {
+			exclude = append(exclude, strings.TrimSpace(t))
+		}
+	}
+	// zero is a valid seed, so check if it's given
+	isSeedSet := p.parser.FindOptionByLongName("seed").IsSet()
+	var columns []string
+	if len(p.opts.Columns) > 0 {
+		for c := range strings.SplitSeq(p.opts.Columns, ",") {
+			columns = append(columns, strings.TrimSpace(c))
+		}
 	}
 	return &Options{
-		CIDR:        args[0],
-		IsVerbose:   p.opts.Verbose,
-		UsePing:     p.opts.Ping,
-		UseNbstat:   p.opts.Nbstat,
-		UseTCPScan:  p.opts.Tcp,
-		UseArpCache: p.opts.Arp,
-		Threads:     p.opts.Threads,
+		Targets:         args,
+		Auto:            p.opts.Auto || (len(args) == 0 && len(p.opts.TargetsFile) == 0),
+		TargetsFile:     p.opts.TargetsFile,
+		Exclude:         exclude,
+		ExcludeFile:     p.opts.ExcludeFile,
+		AllowPublic:     p.opts.AllowPublic,
+		MaxHosts:        p.opts.MaxHosts,
+		IPv6Strategies:  p.opts.IPv6Strategies,
+		AssumeYes:       p.opts.Yes,
+		IsVerbose:       p.opts.Verbose,
+		UsePing:         p.opts.Ping,
+		UseNbstat:       p.opts.Nbstat,
+		UseTCPScan:      p.opts.Tcp,
+		UseArpCache:     p.opts.Arp,
+		UseMDNS:         p.opts.MDNS,
+		UseLLMNR:        p.opts.LLMNR,
+		UseRDNS:         p.opts.RDNS,
+		DNSServer:       p.opts.DNSServer,
+		RDNSThreads:     p.opts.RDNSThreads,
+		RDNSTimeout:     p.opts.RDNSTimeout,
+		MDNSTimeout:     p.opts.MDNSTimeout,
+		LLMNRTimeout:    p.opts.LLMNRTimeout,
+		Threads:         p.opts.Threads,
+		Randomize:       p.opts.Randomize || isSeedSet,
+		Seed:            p.opts.Seed,
+		IsSeedSet:       isSeedSet,
+		PortThreads:     p.opts.PortThreads,
+		Rate:            p.opts.Rate,
+		Burst:           p.opts.Burst,
+		Ports:           p.opts.Ports,
+		DiscoveryOnly:   p.opts.Discover,
+		Timeout:         p.opts.Timeout,
+		TCPTimeout:      p.opts.TCPTimeout,
+		NbstatTimeout:   p.opts.NbstatTimeout,
+		PingTimeout:     p.opts.PingTimeout,
+		MaxScanTime:     p.opts.MaxScanTime,
+		AdaptiveTimeout: p.opts.AdaptiveTimeout,
+		Retries:         p.opts.Retries,
+		OutputFormat:    p.opts.Output,
+		OutputColumns:   columns,
+		HTMLReportPath:  p.opts.HTML,
 	}, nil
 }
@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Returns the scan progress description, like
+// "1234 of 16777214 addresses (0.01%), ETA 3h12m5s".
+// The ETA is extrapolated from the elapsed time once
+// some of the addresses are done.
+func FormatProgress(done, total uint64, elapsed time.Duration) string {
+	if total == 0 {
+		return ""
+	}
+	str := fmt.Sprintf("%d of %d addresses (%.2f%%)", done, total, float64(done)/float64(total)*100)
+	if done == 0 || done >= total {
+		return str
+	}
+	eta := float64(elapsed) * float64(total-done) / float64(done)
+	if eta < math.MaxInt64 {
+		str += ", ETA " + time.Duration(eta).Round(time.Second).String()
+	}
+	return str
+}
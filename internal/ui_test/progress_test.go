@@ -0,0 +1,22 @@
+package uitest
+
+import (
+	"netscan/internal/ui"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatProgress(t *testing.T) {
+	assert.Equal(t, "0 of 254 addresses (0.00%)", ui.FormatProgress(0, 254, time.Second))
+	assert.Equal(t, "127 of 254 addresses (50.00%), ETA 10s", ui.FormatProgress(127, 254, 10*time.Second))
+	assert.Equal(t, "254 of 254 addresses (100.00%)", ui.FormatProgress(254, 254, 20*time.Second))
+	assert.Empty(t, ui.FormatProgress(0, 0, time.Second))
+
+	// 64-bit counts, the ETA is omitted if it overflows
+	assert.Equal(t, "1 of 9223372036854775808 addresses (0.00%)",
+		ui.FormatProgress(1, 1<<63, time.Second))
+	assert.Equal(t, "1099511627776 of 9223372036854775808 addresses (0.00%), ETA 2330h10m7s",
+		ui.FormatProgress(1<<40, 1<<63, time.Second))
+}
@@ -1,18 +1,23 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"math/rand/v2"
 	"netscan/internal/network"
 	"netscan/internal/network/arp"
 	"netscan/internal/network/scanners"
+	"netscan/internal/network/throttle"
+	"netscan/internal/report"
 	"netscan/internal/ui"
 	"os"
 	"os/signal"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -52,14 +57,110 @@ func main() {
 		os.Exit(1)
 	}
 
-	// parse and validate CIDR/address
+	// keep stdout clean for machine-readable formats
+	if report.IsMachineReadable(options.OutputFormat) {
+		ui.RedirectMessagesToStderr()
+	}
+
+	// parse and validate targets; the exclusions go first,
+	// so that the range size limit applies to what remains
 	addrParser := network.NewAddrParser()
 	addrParser.SetVerbosity(options.IsVerbose)
-	err = addrParser.ParseCidrOrAddr(options.CIDR)
+	addrParser.SetAllowPublic(options.AllowPublic)
+	addrParser.SetMaxHosts(options.MaxHosts)
+	ipv6Strategies, err := network.ParseIPv6Strategies(options.IPv6Strategies)
+	if err != nil {
+		ui.PrintflnLabeledError("Error parsing IPv6 strategies: %v\n", err)
+		os.Exit(1)
+	}
+	if len(ipv6Strategies) > 0 {
+		addrParser.SetIPv6Generator(network.NewIPv6TargetsGenerator(&network.IPv6TargetsOptions{
+			Strategies: ipv6Strategies,
+			Timeout:    cmp.Or(options.Timeout, scanners.DefaultTimeout),
+		}))
+	}
+	targets := options.Targets
+	if len(options.TargetsFile) > 0 {
+		list, err := readTargetsFile(options.TargetsFile)
+		if err != nil {
+			ui.PrintflnLabeledError("Error reading targets: %v\n", err)
+			os.Exit(1)
+		}
+		targets = append(targets, list...)
+	}
+	exclude := options.Exclude
+	if len(options.ExcludeFile) > 0 {
+		list, err := readTargetsFile(options.ExcludeFile)
+		if err != nil {
+			ui.PrintflnLabeledError("Error reading exclusions: %v\n", err)
+			os.Exit(1)
+		}
+		exclude = append(exclude, list...)
+	}
+	for _, e := range exclude {
+		err = addrParser.Exclude(e)
+		if err != nil {
+			ui.PrintflnLabeledError("Error parsing exclusion %q: %v\n", e, err)
+			os.Exit(1)
+		}
+	}
+	for _, t := range targets {
+		err = addrParser.AddTarget(t)
+		if err != nil {
+			ui.PrintflnLabeledError("Error parsing target %q: %v\n", t, err)
+			if errors.Is(err, network.ErrTooManyHosts) {
+				ui.PrintflnLabeledInfo("Raise the limit with --max-hosts to scan larger ranges")
+			}
+			os.Exit(1)
+		}
+	}
+	// the subnets are also used to label the hosts by interface
+	localSubnets, err := network.GetLocalSubnets()
 	if err != nil {
-		ui.PrintflnLabeledError("Error parsing CIDR/address: %v\n", err)
+		if options.Auto {
+			ui.PrintflnLabeledError("Error listing network interfaces: %v\n", err)
+			os.Exit(1)
+		}
+		// only the interface labels are missing
+		if options.IsVerbose {
+			ui.PrintflnInfo("Error listing network interfaces: %v\n", err)
+		}
+	}
+	if options.Auto {
+		for _, s := range localSubnets {
+			err = addrParser.AddTarget(s.Prefix.String())
+			if err != nil {
+				ui.PrintflnLabeledWarning("Skipping %s subnet %v: %v", s.Interface, s.Prefix, err)
+				continue
+			}
+			ui.PrintflnLabeledInfo("Found %s subnet %v", s.Interface, s.Prefix)
+			targets = append(targets, s.Prefix.String())
+		}
+	}
+	if addrParser.GetHostsLength() == 0 {
+		ui.PrintflnLabeledError("No addresses to scan\n")
 		os.Exit(1)
 	}
+	if addrParser.HasPublicHosts() && !options.AssumeYes {
+		ui.PrintflnLabeledWarning("The targets include PUBLIC addresses outside of the private networks!")
+		ui.PrintflnLabeledWarning("Scanning networks you don't own or aren't authorized to test may be illegal.")
+		confirmOrExit(options, "Scan %d addresses anyway?", addrParser.GetHostsLength())
+	}
+	if addrParser.GetHostsLength() > network.DefaultMaxHosts && !options.AssumeYes {
+		ui.PrintflnLabeledWarning("The targets include %d addresses, the scan may take a long time and flood the network!",
+			addrParser.GetHostsLength())
+		confirmOrExit(options, "Scan them anyway?")
+	}
+
+	// parse TCP ports list
+	tcpPorts := network.DefaultTCPPorts
+	if len(options.Ports) > 0 {
+		tcpPorts, err = network.ParsePorts(options.Ports)
+		if err != nil {
+			ui.PrintflnLabeledError("Error parsing ports: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	// set number of threads if not provided by user
 	if options.Threads == 0 {
@@ -84,32 +185,118 @@ func main() {
 		fmt.Println("Hosts count:", addrParser.GetHostsLength())
 	*/
 
+	resultsWriter, err := report.NewWriter(&report.WriterOptions{
+		Format:  options.OutputFormat,
+		Columns: options.OutputColumns,
+	}, os.Stdout)
+	if err != nil {
+		ui.PrintflnLabeledError("Error configuring output: %v\n", err)
+		os.Exit(1)
+	}
+	streamWriter, isStreaming := resultsWriter.(report.StreamWriter)
+
+	// each worker takes as many threads as its TCP connection attempts,
+	// reserved for the target when it's admitted
+	hostThreads := 1
+	if options.UseTCPScan {
+		hostThreads = scanners.HostThreads(int(options.PortThreads), len(tcpPorts), int(options.Threads))
+	}
+	workers := max(int(options.Threads)/hostThreads, 1)
+	threads := throttle.NewSemaphore(workers)
+	var limiter *throttle.RateLimiter
+	if options.Rate > 0 {
+		limiter = throttle.NewRateLimiter(options.Rate, int(options.Burst))
+	}
+
 	// configure scanners
 	scannerOptions := &scanners.ScannersManagerOptions{
 		IncludeTCPScan:  options.UseTCPScan,
 		IncludeICMPPing: options.UsePing,
 		IncludeNbstat:   options.UseNbstat,
+		IncludeMDNS:     options.UseMDNS,
+		IncludeLLMNR:    options.UseLLMNR,
+		IncludeRDNS:     options.UseRDNS,
+		TCPPorts:        tcpPorts,
+		TCPDiscovery:    options.DiscoveryOnly,
+		TCPHostDials:    hostThreads,
+		DNSServer:       options.DNSServer,
+		RDNSThreads:     int(options.RDNSThreads),
+		Limiter:         limiter,
+		Timeout:         options.Timeout,
+		TCPTimeout:      options.TCPTimeout,
+		NbstatTimeout:   options.NbstatTimeout,
+		PingTimeout:     options.PingTimeout,
+		RDNSTimeout:     options.RDNSTimeout,
+		MDNSTimeout:     options.MDNSTimeout,
+		LLMNRTimeout:    options.LLMNRTimeout,
+		AdaptiveTimeout: options.AdaptiveTimeout,
+		Retries:         int(options.Retries),
 		// TODO more scanner types...
 		IsVerbose: options.IsVerbose,
 	}
 	scannerManager := scanners.NewScannersManager(scannerOptions)
+	defer scannerManager.Close()
 
 	ui.PrintflnInfo("netscan %s", version)
-	ui.PrintflnLabeledInfo("Target: %v", addrParser.GetCIDR())
+	ui.PrintflnLabeledInfo("Target: %s", summarizeTargets(targets))
+	if len(exclude) > 0 {
+		ui.PrintflnLabeledInfo("Excluded: %s", summarizeTargets(exclude))
+	}
+	ui.PrintflnLabeledInfo("Addresses to scan: %d", addrParser.GetHostsLength())
 	scanNames := scannerManager.GetNames()
 	if options.UseArpCache {
 		scanNames = append(scanNames, "ARP Table")
 	}
 	ui.PrintflnLabeledInfo("Scan methods: %s", strings.Join(scanNames, ", "))
-	ui.PrintflnLabeledInfo("Using %d threads", options.Threads)
+	ui.PrintflnLabeledInfo("Using %d threads, %d hosts at once", options.Threads, workers)
+	hosts := addrParser.Hosts()
+	if options.Randomize {
+		if !options.IsSeedSet {
+			options.Seed = rand.Uint64()
+		}
+		hosts = addrParser.HostsShuffled(options.Seed)
+		ui.PrintflnLabeledInfo("Random order seed: %d", options.Seed)
+	}
 	spinnerInfo, _ := pterm.DefaultSpinner.Start("Scanning...")
 
 	// prepare scanning
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	interruptCtx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
+	ctx := interruptCtx
+	if options.MaxScanTime > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, options.MaxScanTime)
+		defer cancelTimeout()
+	}
 
 	results := []*scanners.TargetInfo{}
 	var muResults sync.Mutex
+	scanInfo := &report.ScanInfo{
+		Version:     version,
+		CommandLine: strings.Join(os.Args, " "),
+		Target:      strings.Join(targets, ", "),
+		HostsCount:  addrParser.GetHostsLength(),
+		Scanners:    scanNames,
+		StartTime:   time.Now(),
+	}
+
+	// show the progress
+	var scanned atomic.Uint64
+	var wgProgress sync.WaitGroup
+	progressDone := make(chan struct{})
+	wgProgress.Go(func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-progressDone:
+				return
+			case <-ticker.C:
+				spinnerInfo.UpdateText("Scanning... " + ui.FormatProgress(
+					scanned.Load(), addrParser.GetHostsLength(), time.Since(scanInfo.StartTime)))
+			}
+		}
+	})
 
 	// start scanning
 	var wg sync.WaitGroup
@@ -118,15 +305,18 @@ func main() {
 		var wgConsumer sync.WaitGroup
 		out := make(chan *scanners.TargetInfo, options.Threads)
 		wgConsumer.Go(func() {
+			// drain the channel even if the scan is interrupted,
+			// so that the workers don't block
 			for r := range out {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-					if r.GetState() != scanners.HostDead {
-						muResults.Lock()
-						results = append(results, r)
-						muResults.Unlock()
+				if r.GetState() != scanners.HostDead {
+					muResults.Lock()
+					results = append(results, r)
+					muResults.Unlock()
+					if isStreaming {
+						err := streamWriter.WriteHost(r)
+						if err != nil && options.IsVerbose {
+							ui.PrintflnInfo("Error writing %v: %v\n", r.Address, err)
+						}
 					}
 				}
 			}
@@ -134,50 +324,70 @@ func main() {
 
 		// run a number of workers limited by options.Threads
 		var wgWorkers sync.WaitGroup
-		sem := make(chan struct{}, options.Threads)
-		for addr := range addrParser.Hosts() {
+	queue:
+		for addr := range hosts {
 			select {
 			case <-ctx.Done():
-				return
+				// interrupted or the time limit is reached,
+				// report the results gathered so far
+				break queue
 			default:
 				// acquire the semaphore and run worker
-				sem <- struct{}{}
+				if threads.Acquire(ctx) != nil {
+					break queue
+				}
 
 				if options.IsVerbose {
 					ui.PrintflnInfo("Queued %v\n", addr)
 				}
 				// execute scanning steps and send the result
 				wgWorkers.Go(func() {
-					defer func() { <-sem }()
+					defer threads.Release()
 					if options.IsVerbose {
 						ui.PrintflnInfo("Scanning %v\n", addr)
 					}
 					steps := scannerManager.GetSteps()
 					target := &scanners.TargetInfo{
-						Address: addr,
+						Address:    addr,
+						Interface:  network.GetInterfaceName(localSubnets, addr),
+						TargetName: addrParser.GetTargetName(addr),
 					}
 					for step := range steps {
 						select {
 						case <-ctx.Done():
 							return
 						default:
-							scanner, err := scannerManager.GetScanner(step)
-							if err != nil {
-								continue
+							err := scannerManager.Scan(ctx, step, target)
+							if err != nil && options.IsVerbose && ctx.Err() == nil &&
+								!errors.Is(err, scanners.ErrDefinitelyNegative) {
+								ui.PrintflnInfo("Error scanning %v: %v\n", addr, err)
 							}
-							// TODO get rid of the hardcoded timeout
-							scanner.ScanTimeout(ctx, target, 1*time.Second)
 						}
 					}
 					out <- target
+					scanned.Add(1)
 				})
 			}
 		}
 		wgWorkers.Wait()
 		close(out)
-		close(sem)
 		wgConsumer.Wait()
 
+		// merge the hosts discovered by the scanners on their own,
+		// like mDNS services browsing; that's done for the partial
+		// results too, so the context must not be cancelled
+		discovered := scannerManager.Discovered(context.WithoutCancel(ctx))
+		muResults.Lock()
+		for _, d := range scanners.MergeDiscovered(results, discovered) {
+			if !addrParser.Contains(d.Address) {
+				continue
+			}
+			d.Interface = network.GetInterfaceName(localSubnets, d.Address)
+			d.TargetName = addrParser.GetTargetName(d.Address)
+			results = append(results, d)
+		}
+		muResults.Unlock()
+
 		// enrich results with ARP cache contents
 		if options.UseArpCache {
 			arp, err := arp.GetArpTable()
@@ -192,17 +402,18 @@ func main() {
 					m.IsProcessed = true
 				}
 				muResults.Unlock()
-				targetCIDR := addrParser.GetCIDR()
 				for ip, m := range arp {
 					if m.IsProcessed {
 						continue
 					}
-					if !targetCIDR.Contains(ip) {
+					if !addrParser.Contains(ip) {
 						continue
 					}
 					res := scanners.TargetInfo{
-						Address: ip,
-						Mac:     m.Mac,
+						Address:    ip,
+						Mac:        m.Mac,
+						Interface:  network.GetInterfaceName(localSubnets, ip),
+						TargetName: addrParser.GetTargetName(ip),
 					}
 					res.SetState(scanners.HostUnknown)
 					muResults.Lock()
@@ -212,6 +423,15 @@ func main() {
 			}
 		}
 
+		// enrich all the hosts found, e.g. with DNS names;
+		// that's done when the time limit is reached, but not on interrupt
+		muResults.Lock()
+		err := scannerManager.Enrich(interruptCtx, results)
+		muResults.Unlock()
+		if err != nil && options.IsVerbose && interruptCtx.Err() == nil {
+			ui.PrintflnInfo("Error enriching the results: %v\n", err)
+		}
+
 		// sort the results
 		muResults.Lock()
 		sort.Slice(results, func(i, j int) bool {
@@ -220,10 +440,18 @@ func main() {
 		muResults.Unlock()
 	})
 	wg.Wait()
+	scanInfo.EndTime = time.Now()
+	close(progressDone)
+	wgProgress.Wait()
 
 	select {
 	case <-ctx.Done():
-		spinnerInfo.UpdateText("Interrupted!")
+		scanInfo.Interrupted = true
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			spinnerInfo.UpdateText("Time limit reached!")
+		} else {
+			spinnerInfo.UpdateText("Interrupted!")
+		}
 		spinnerInfo.Warning()
 	default:
 		spinnerInfo.UpdateText("Finished!")
@@ -231,33 +459,17 @@ func main() {
 	}
 
 	// process the results
-	// TODO refact this spaghetti
-	fmt.Println()
-	for _, r := range results {
-		state := r.GetState()
-		if state != scanners.HostAlive && state != scanners.HostUnknown {
-			fmt.Printf("Scanned %v with state %s\n", r.Address, state)
+	err = resultsWriter.Write(scanInfo, results)
+	if err != nil {
+		ui.PrintflnLabeledError("Error writing results: %v\n", err)
+	}
+	if len(options.HTMLReportPath) > 0 {
+		err = writeHTMLReport(options.HTMLReportPath, scanInfo, results)
+		if err != nil {
+			ui.PrintflnLabeledError("Error saving HTML report: %v\n", err)
 		} else {
-			if state == scanners.HostAlive {
-				ui.PrintflnSuccess("%v is %s", r.Address, state)
-			}
-			if state == scanners.HostUnknown {
-				ui.PrintflnWarn("%v is %s", r.Address, state)
-			}
-			if len(r.Mac) > 0 {
-				fmt.Printf("\t%s\n", r.Mac)
-			}
-			if len(r.HostName) > 0 {
-				fmt.Printf("\t%s\n", r.HostName)
-			}
-			if len(r.Workgroup) > 0 {
-				fmt.Printf("\t%s\n", r.Workgroup)
-			}
-		}
-		for _, c := range r.Comments {
-			fmt.Printf("\t\t%s\n", c)
+			ui.PrintflnLabeledInfo("HTML report saved to %s", options.HTMLReportPath)
 		}
-		fmt.Println()
 	}
 	/*
 		// Debug
@@ -269,3 +481,57 @@ func main() {
 	// grant time for goroutines to finish
 	time.Sleep(500 * time.Millisecond)
 }
+
+// Reads a list of targets from the file, or the standard input if path is "-".
+func readTargetsFile(path string) ([]string, error) {
+	if path == "-" {
+		return network.ReadTargets(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return network.ReadTargets(f)
+}
+
+// Asks the user to confirm, exits if the answer is no or can't be asked.
+func confirmOrExit(options *ui.Options, format string, a ...any) {
+	// the standard input is already read to the end
+	if options.TargetsFile == "-" || options.ExcludeFile == "-" {
+		ui.PrintflnLabeledError("Can't ask for confirmation with the targets read from the standard input, use --yes\n")
+		os.Exit(1)
+	}
+	ok, err := ui.Confirm(format, a...)
+	if err != nil {
+		ui.PrintflnLabeledError("Can't ask for confirmation, %v, use --yes\n", err)
+		os.Exit(1)
+	}
+	if !ok {
+		ui.PrintflnLabeledError("Aborted, use --yes to skip the confirmation\n")
+		os.Exit(1)
+	}
+}
+
+// Returns a short comma-separated list of the targets for display.
+func summarizeTargets(targets []string) string {
+	const maxShown = 8
+	if len(targets) <= maxShown {
+		return strings.Join(targets, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(targets[:maxShown], ", "), len(targets)-maxShown)
+}
+
+// Saves the scan results as an HTML report file.
+func writeHTMLReport(path string, info *report.ScanInfo, results []*scanners.TargetInfo) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = report.NewHTMLWriter(f).Write(info, results)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}